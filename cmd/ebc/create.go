@@ -0,0 +1,167 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/sqs/go-elasticbeanstalk/elasticbeanstalk"
+)
+
+func createCmd(args []string) {
+	df, err := readDefaults(*dir)
+	if err != nil {
+		if *verbose {
+			log.Printf("Warning: couldn't read defaults: %s. Flag values must be explicitly specified.", err)
+		}
+		df = new(defaults)
+	}
+
+	fs := flag.NewFlagSet("create", flag.ExitOnError)
+	env := fs.String("env", "", "name of the EB environment to create")
+	app := fs.String("app", df.app, "EB application name")
+	cname := fs.String("cname", "", "CNAME prefix of the environment (such as myapp for myapp.REGION.elasticbeanstalk.com)")
+	stack := fs.String("stack", "", "solution stack name of the environment (such as \"64bit Amazon Linux 2 v3.4.0 running Go 1\")")
+	template := fs.String("template", "", "name of the saved configuration template to create the environment from")
+	description := fs.String("description", "", "description of the environment")
+	withDeploy := fs.Bool("deploy", false, "bundle and upload the directory (specified with -dir=DIR), and launch the environment with it and its env vars")
+	bucket := fs.String("bucket", df.bucketURL, "S3 bucket URL, with -deploy (example: https://example-bucket.s3-us-west-2.amazonaws.com)")
+	label := fs.String("label", df.label, "label base name, with -deploy (suffix of -0, -1, -2, etc., is appended to ensure uniqueness)")
+	wait := fs.Bool("wait", false, "wait for the environment to launch, printing its events, and fail if it doesn't become Ready")
+	timeout := fs.Duration("timeout", 30*time.Minute, "how long to wait for the environment to launch (with -wait)")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: ebc create [OPTS] -env NAME -cname PREFIX -stack STACK|-template TEMPLATE\n")
+		fmt.Fprintln(os.Stderr)
+		fmt.Fprintln(os.Stderr, "Creates an environment, after checking that its CNAME prefix is available. Unless -deploy is given, the environment runs the solution stack's sample application.")
+		fmt.Fprintln(os.Stderr)
+		fmt.Fprintln(os.Stderr, "With -deploy, the directory is bundled and uploaded as the environment's first version, and the env vars read from its layers (see `ebc deploy -h`) are set on the environment.")
+		fmt.Fprintln(os.Stderr)
+		fs.PrintDefaults()
+		fmt.Fprintln(os.Stderr)
+		os.Exit(1)
+	}
+	fs.Parse(args)
+
+	if *env == "" {
+		fmt.Fprintln(os.Stderr, "env is required")
+		fs.Usage()
+	}
+
+	if *app == "" {
+		fmt.Fprintln(os.Stderr, "app is required")
+		fs.Usage()
+	}
+
+	if *cname == "" {
+		fmt.Fprintln(os.Stderr, "cname is required")
+		fs.Usage()
+	}
+
+	if (*stack == "") == (*template == "") {
+		fmt.Fprintln(os.Stderr, "exactly one of stack and template is required")
+		fs.Usage()
+	}
+
+	var bucketURL *url.URL
+	if *withDeploy {
+		if *bucket == "" {
+			fmt.Fprintln(os.Stderr, "bucket is required with -deploy")
+			fs.Usage()
+		}
+		bucketURL, err = url.Parse(*bucket)
+		if err != nil {
+			log.Fatal("parsing bucket URL:", err)
+		}
+
+		if *label == "" {
+			fmt.Fprintln(os.Stderr, "label is required with -deploy")
+			fs.Usage()
+		}
+	}
+
+	if fs.NArg() != 0 {
+		fmt.Fprintln(os.Stderr, "no positional args")
+		fs.Usage()
+	}
+
+	p := &elasticbeanstalk.CreateEnvironmentParams{
+		ApplicationName:   *app,
+		EnvironmentName:   *env,
+		CNAMEPrefix:       *cname,
+		Description:       *description,
+		SolutionStackName: *stack,
+		TemplateName:      *template,
+	}
+	if err := createEnvironment(p, *withDeploy, bucketURL, *label); err != nil {
+		log.Fatal("create failed: ", err)
+	}
+
+	if *dryRun {
+		fmt.Printf("Create planned (took %s)\n", time.Since(t0))
+		return
+	}
+	if *wait {
+		e, err := waitForReady(*app, *env, t0, *timeout)
+		if err != nil {
+			log.Fatal("create failed: ", err)
+		}
+		fmt.Printf("Create completed: %s is running at http://%s (took %s)\n", *env, e.CNAME, time.Since(t0))
+	} else {
+		fmt.Printf("Create initiated (took %s)\n", time.Since(t0))
+	}
+}
+
+// createEnvironment checks that the CNAME prefix in p is available and then
+// creates the environment. If withDeploy is true, the directory's env vars are
+// added to p, and the directory is bundled and uploaded as the environment's
+// first version.
+func createEnvironment(p *elasticbeanstalk.CreateEnvironmentParams, withDeploy bool, bucketURL *url.URL, label string) error {
+	res, err := ebClient.CheckDNSAvailability(&elasticbeanstalk.CheckDNSAvailabilityParams{CNAMEPrefix: p.CNAMEPrefix})
+	if err != nil {
+		return fmt.Errorf("checking CNAME availability failed: %s", err)
+	}
+	if res == nil || !res.Available {
+		return fmt.Errorf("CNAME prefix %q is not available", p.CNAMEPrefix)
+	}
+
+	if withDeploy {
+		// Get and check env vars before bundling and uploading, so that
+		// invalid vars are reported before anything is changed.
+		vars, err := readEnvVars(*dir, p.EnvironmentName, p.ApplicationName, true)
+		if err != nil {
+			return err
+		}
+		for _, v := range vars {
+			if *verbose {
+				log.Printf(" - %s (from %s)", v.Name, v.Source)
+			}
+			p.OptionSettings.Set(elasticbeanstalk.EnvKey(v.Name), v.Value)
+		}
+		if err := p.OptionSettings.ValidateEnv(); err != nil {
+			return err
+		}
+		if err := checkEnvSchema(*dir, p.EnvironmentName, p.OptionSettings.Environ()); err != nil {
+			return err
+		}
+		printEnvChanges(p.EnvironmentName, elasticbeanstalk.ConfigurationOptionSettings(nil).Diff(p.OptionSettings))
+
+		var buf bytes.Buffer
+		if err := bundle(*dir, &buf); err != nil {
+			return fmt.Errorf("bundle failed: %s", err)
+		}
+		p.VersionLabel, err = upload(&buf, p.ApplicationName, bucketURL, label)
+		if err != nil {
+			return fmt.Errorf("upload failed: %s", err)
+		}
+	}
+
+	fmt.Printf("Creating environment %s at %s\n", p.EnvironmentName, res.FullyQualifiedCNAME)
+	if err := ebClient.CreateEnvironment(p); err != nil {
+		return fmt.Errorf("creating environment failed: %s", err)
+	}
+	return nil
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"archive/zip"
+	"bufio"
 	"bytes"
 	"flag"
 	"fmt"
@@ -12,23 +13,35 @@ import (
 	"net/url"
 	"os"
 	"os/exec"
+	"path"
 	"path/filepath"
+	"sort"
 	"strings"
+	"text/tabwriter"
 	"time"
 
 	"github.com/crowdmob/goamz/aws"
 	"github.com/jteeuwen/ini"
 	"github.com/kr/s3"
 	"github.com/kr/s3/s3util"
+	"github.com/sqs/go-elasticbeanstalk/ebcvars"
+	"github.com/sqs/go-elasticbeanstalk/ebextensions"
 	"github.com/sqs/go-elasticbeanstalk/elasticbeanstalk"
 )
 
 var dir = flag.String("dir", ".", "dir to operate in")
 var verbose = flag.Bool("v", false, "show verbose output")
 var debugKeepTempDirs = flag.Bool("debug.keep-temp-dirs", false, "(debug) don't remove temp dirs")
+var auditLog = flag.String("audit-log", "", "append a JSON line describing each change made to AWS resources to this file")
+var rateLimit = flag.Float64("rate", 0, "maximum Elastic Beanstalk API requests per second (0 means unlimited)")
+var dryRun = flag.Bool("dry-run", false, "print the changes that would be made to AWS resources, without making them")
 
 var elasticbeanstalkURL *url.URL
-var ebClient *elasticbeanstalk.Client
+var ebClient elasticbeanstalk.API
+
+// ebDryRun records the mutating operations that ebClient would have made, if
+// -dry-run is set.
+var ebDryRun *elasticbeanstalk.DryRun
 
 var t0 = time.Now()
 
@@ -50,9 +63,28 @@ func initEnv() {
 	if err != nil {
 		log.Fatal(err)
 	}
-	ebClient = &elasticbeanstalk.Client{BaseURL: elasticbeanstalkURL, Auth: auth, Region: aws.Regions[region]}
+	c := &elasticbeanstalk.Client{BaseURL: elasticbeanstalkURL, Auth: auth, Region: aws.Regions[region]}
+	if *dryRun {
+		ebDryRun = &elasticbeanstalk.DryRun{}
+		c.DryRun = ebDryRun
+	}
+	if *rateLimit > 0 {
+		c.Limiter = elasticbeanstalk.NewLimiter(*rateLimit, 1)
+	}
+	if *auditLog != "" {
+		f, err := os.OpenFile(*auditLog, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+		if err != nil {
+			log.Fatal("Opening audit log: ", err)
+		}
+		c.Audit = elasticbeanstalk.NewJSONLinesAuditSink(f)
+	}
+	ebClient = c
 }
 
+// offlineCmds are the commands that don't use the Elastic Beanstalk API, and
+// so don't require ELASTICBEANSTALK_URL to be set.
+var offlineCmds = map[string]bool{"lint": true, "secrets": true}
+
 func main() {
 	flag.Usage = func() {
 		fmt.Fprintf(os.Stderr, "Usage: ebc command [OPTS] ARGS...\n")
@@ -62,6 +94,17 @@ func main() {
 		fmt.Fprintln(os.Stderr, "\tbundle\t creates a source bundle for a directory (running scripts if they exist)")
 		fmt.Fprintln(os.Stderr, "\tdeploy\t deploys a directory")
 		fmt.Fprintln(os.Stderr, "\tupload BUNDLE-FILE\t uploads the source bundle")
+		fmt.Fprintln(os.Stderr, "\tlint\t checks a directory's .ebextensions config files for errors")
+		fmt.Fprintln(os.Stderr, "\tsecrets\t manages encrypted env var values")
+		fmt.Fprintln(os.Stderr, "\tstatus\t shows an environment's status, health, version, and recent events")
+		fmt.Fprintln(os.Stderr, "\tevents\t prints an environment's events (and follows new ones with -f)")
+		fmt.Fprintln(os.Stderr, "\tlogs\t prints or downloads the logs of an environment's instances")
+		fmt.Fprintln(os.Stderr, "\tenv\t lists, sets, unsets, pulls, or copies an environment's env vars without deploying")
+		fmt.Fprintln(os.Stderr, "\tversions\t lists or prunes application versions and their source bundles")
+		fmt.Fprintln(os.Stderr, "\trollback\t redeploys the previous (or a given) application version")
+		fmt.Fprintln(os.Stderr, "\tswap\t swaps the CNAMEs of two environments")
+		fmt.Fprintln(os.Stderr, "\tcreate\t creates an environment (and optionally deploys a directory to it)")
+		fmt.Fprintln(os.Stderr, "\tterminate\t terminates an environment")
 		fmt.Fprintln(os.Stderr)
 		flag.PrintDefaults()
 		fmt.Fprintln(os.Stderr)
@@ -76,7 +119,15 @@ func main() {
 	}
 
 	flag.Parse()
-	initEnv()
+	if flag.NArg() == 0 {
+		flag.Usage()
+	}
+
+	subcmd := flag.Arg(0)
+	remaining := flag.Args()[1:]
+	if !offlineCmds[subcmd] {
+		initEnv()
+	}
 
 	var err error
 	*dir, err = filepath.Abs(*dir)
@@ -84,13 +135,8 @@ func main() {
 		log.Fatal(err)
 	}
 
-	if flag.NArg() == 0 {
-		flag.Usage()
-	}
 	log.SetFlags(0)
 
-	subcmd := flag.Arg(0)
-	remaining := flag.Args()[1:]
 	switch subcmd {
 	case "bundle":
 		bundleCmd(remaining)
@@ -98,6 +144,55 @@ func main() {
 		deployCmd(remaining)
 	case "upload":
 		uploadCmd(remaining)
+	case "lint":
+		lintCmd(remaining)
+	case "secrets":
+		secretsCmd(remaining)
+	case "status":
+		statusCmd(remaining)
+	case "events":
+		eventsCmd(remaining)
+	case "logs":
+		logsCmd(remaining)
+	case "env":
+		envCmd(remaining)
+	case "versions":
+		versionsCmd(remaining)
+	case "rollback":
+		rollbackCmd(remaining)
+	case "swap":
+		swapCmd(remaining)
+	case "create":
+		createCmd(remaining)
+	case "terminate":
+		terminateCmd(remaining)
+	}
+
+	if ebDryRun != nil {
+		printPlan(ebDryRun.Operations())
+	}
+}
+
+// printPlan prints the operations that were recorded instead of sent
+// because -dry-run is set.
+func printPlan(ops []*elasticbeanstalk.Operation) {
+	fmt.Println()
+	if len(ops) == 0 {
+		fmt.Println("Dry run: no changes would be made.")
+		return
+	}
+	fmt.Printf("Dry run: the following %d operation(s) would be made:\n", len(ops))
+	for i, op := range ops {
+		fmt.Printf("\n%d. %s\n", i+1, op.Name)
+		params := op.RedactedParams()
+		keys := make([]string, 0, len(params))
+		for k := range params {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			fmt.Printf("     %s = %q\n", k, params.Get(k))
+		}
 	}
 }
 
@@ -111,6 +206,9 @@ func bundleCmd(args []string) {
 		fmt.Fprintln(os.Stderr)
 		fmt.Fprintf(os.Stderr, "Creates a source bundle for a directory (specified with -dir=DIR). If the directory contains an %s file, it is executed with a temporary output directory as its first argument, and it's expected to write the source bundle to that directory. Otherwise, if no %s file exists, the directory itself is used as the bundle source.", bundleScript, bundleScript)
 		fmt.Fprintln(os.Stderr)
+		fmt.Fprintln(os.Stderr)
+		fmt.Fprintf(os.Stderr, "If the directory contains an %s file, it is executed with the bundle's %s directory as its first argument, and it may write generated config files (see the ebextensions package) there before the bundle is zipped.", ebextensionsScript, ebextensions.Dir)
+		fmt.Fprintln(os.Stderr)
 		fs.PrintDefaults()
 		fmt.Fprintln(os.Stderr)
 		os.Exit(1)
@@ -140,6 +238,7 @@ func bundleCmd(args []string) {
 }
 
 func bundle(dir string, w io.Writer) error {
+	srcDir := dir
 	scriptFile := filepath.Join(dir, bundleScript)
 	fi, err := os.Stat(scriptFile)
 	if err == nil && fi.Mode().IsRegular() {
@@ -167,9 +266,69 @@ func bundle(dir string, w io.Writer) error {
 		dir = tmpDir
 	}
 
+	genFile := filepath.Join(srcDir, ebextensionsScript)
+	fi, err = os.Stat(genFile)
+	if err == nil && fi.Mode().IsRegular() {
+		if dir == srcDir {
+			// Don't write generated files into the source directory.
+			tmpDir, err := ioutil.TempDir("", "ebc")
+			if err != nil {
+				return err
+			}
+			if *debugKeepTempDirs {
+				log.Printf("Copying bundle source to temp dir %s", tmpDir)
+			} else {
+				defer os.RemoveAll(tmpDir)
+			}
+			if err := copyDir(dir, tmpDir); err != nil {
+				return err
+			}
+			dir = tmpDir
+		}
+		if err := generateEbextensions(srcDir, genFile, dir); err != nil {
+			return err
+		}
+	}
+
 	return writeZipArchive(dir, w)
 }
 
+const ebextensionsScript = ".ebc-ebextensions"
+
+// generateEbextensions runs the executable genFile (in srcDir) with the
+// .ebextensions directory of the bundle directory bundleDir as its first
+// argument, and checks the config files in bundleDir afterwards.
+func generateEbextensions(srcDir, genFile, bundleDir string) error {
+	outDir := filepath.Join(bundleDir, ebextensions.Dir)
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		return err
+	}
+	if *verbose {
+		log.Printf("Running ebextensions script %s...", genFile)
+	}
+	script := exec.Command(genFile, outDir)
+	script.Dir = srcDir
+	if *verbose {
+		script.Stdout, script.Stderr = os.Stderr, os.Stderr
+	}
+	if err := script.Run(); err != nil {
+		return fmt.Errorf("running %s: %s", genFile, err)
+	}
+	if _, err := ebextensions.ParseDir(bundleDir); err != nil {
+		return fmt.Errorf("invalid config files after running %s:\n%s", genFile, err)
+	}
+	return nil
+}
+
+func copyDir(src, dst string) error {
+	cp := exec.Command("cp", "-R", src+"/.", dst)
+	cp.Stderr = os.Stderr
+	if err := cp.Run(); err != nil {
+		return fmt.Errorf("copying %s to %s: %s", src, dst, err)
+	}
+	return nil
+}
+
 type defaults struct {
 	env       string
 	app       string
@@ -306,21 +465,25 @@ func upload(r io.Reader, app string, bucketURL *url.URL, label string) (string,
 		return "", fmt.Errorf("making bundle S3 object URL failed: %s", err)
 	}
 
-	if *verbose {
-		log.Printf("Uploading source bundle to %s...", u.String())
-	}
+	if *dryRun {
+		log.Printf("Dry run: not uploading source bundle to %s", u.String())
+	} else {
+		if *verbose {
+			log.Printf("Uploading source bundle to %s...", u.String())
+		}
 
-	w, err := s3util.Create(u.String(), nil, &s3Config)
-	if err != nil {
-		return "", fmt.Errorf("creating S3 object failed: %s", err)
-	}
-	_, err = io.Copy(w, r)
-	if err != nil {
-		return "", err
-	}
-	err = w.Close()
-	if err != nil {
-		return "", err
+		w, err := s3util.Create(u.String(), nil, &s3Config)
+		if err != nil {
+			return "", fmt.Errorf("creating S3 object failed: %s", err)
+		}
+		_, err = io.Copy(w, r)
+		if err != nil {
+			return "", err
+		}
+		err = w.Close()
+		if err != nil {
+			return "", err
+		}
 	}
 
 	if *verbose {
@@ -361,18 +524,52 @@ func deployCmd(args []string) {
 	app := fs.String("app", df.app, "EB application name")
 	bucket := fs.String("bucket", df.bucketURL, "S3 bucket URL (example: https://example-bucket.s3-us-west-2.amazonaws.com)")
 	label := fs.String("label", df.label, "label base name (suffix of -0, -1, -2, etc., is appended to ensure uniqueness)")
+	varsExplain := fs.Bool("vars-explain", false, "show which layer sets each env var, and exit without deploying")
+	var opts deployOptions
+	fs.BoolVar(&opts.removeMissing, "remove-missing", false, "remove env vars that are set in the environment but not by any layer")
+	fs.BoolVar(&opts.yes, "yes", false, "deploy to protected environments without asking for confirmation")
+	protected := fs.String("protected", "", "comma-separated glob patterns (such as \"*prod*\") of protected environment names, which require confirmation to deploy to")
+	fs.BoolVar(&opts.wait, "wait", false, "wait for the deploy to finish, printing the environment's events, and fail if it doesn't succeed")
+	fs.DurationVar(&opts.timeout, "timeout", 15*time.Minute, "how long to wait for the deploy to finish (with -wait)")
+	blueGreen := fs.Bool("blue-green", false, "deploy to whichever of -envs isn't live (doesn't have -cname), and swap CNAMEs once it's Green")
+	var bg blueGreenOptions
+	bgEnvs := fs.String("envs", "", "comma-separated names of the 2 environments that take turns being live (with -blue-green)")
+	fs.StringVar(&bg.cname, "cname", "", "CNAME of the live environment, or its first label, such as myapp (with -blue-green)")
+	fs.BoolVar(&bg.scaleDown, "scale-down", false, "scale the formerly live environment down to 1 instance after swapping (with -blue-green)")
 	fs.Usage = func() {
 		fmt.Fprintf(os.Stderr, "Usage: ebc deploy [OPTS]\n")
 		fmt.Fprintln(os.Stderr)
 		fmt.Fprintln(os.Stderr, "Bundles and deploys a directory (specified with -dir=DIR).")
 		fmt.Fprintln(os.Stderr)
+		fmt.Fprintln(os.Stderr, "Before deploying, the changes to the environment's env vars are shown (without their values). Deploying to a protected environment (see -protected) requires confirmation.")
+		fmt.Fprintln(os.Stderr)
+		fmt.Fprintf(os.Stderr, "With -blue-green, the directory is deployed to whichever of -envs doesn't have the live -cname (scaled like the live environment), and once it is Ready and Green (and the %s script in the directory, if any, succeeds when run with its URL as an argument), the environments' CNAMEs are swapped.\n", smokeScript)
+		fmt.Fprintln(os.Stderr)
+		fmt.Fprintln(os.Stderr, "With -wait, the command waits until the environment is Ready again, and exits with an error if the deploy reports an ERROR event, leaves the environment Red, or ends with the environment running a different version (for example, because the deploy was rolled back).")
+		fmt.Fprintln(os.Stderr)
+		fmt.Fprintf(os.Stderr, "If the directory contains a %s file, the environment's env vars after the deploy are checked against it, and the deploy is refused if any are missing or invalid.\n", varsSchemaFile)
+		fmt.Fprintln(os.Stderr)
+		fmt.Fprintf(os.Stderr, "Env vars are read from the following layers in the directory, with later layers overriding earlier ones: %s/global.env, %s/APP.env, %s/ENV.env, and the output of the .ebc-vars script (run with ENV and APP as arguments) or, if there is no script, the .ebc-vars.env file.\n", varsDir, varsDir, varsDir)
+		fmt.Fprintln(os.Stderr)
 		fs.PrintDefaults()
 		fmt.Fprintln(os.Stderr)
 		os.Exit(1)
 	}
 	fs.Parse(args)
 
-	if *env == "" {
+	if *blueGreen {
+		if *bgEnvs != "" {
+			bg.envs = strings.Split(*bgEnvs, ",")
+		}
+		if len(bg.envs) != 2 || bg.envs[0] == bg.envs[1] {
+			fmt.Fprintln(os.Stderr, "envs must name 2 different environments with -blue-green")
+			fs.Usage()
+		}
+		if bg.cname == "" {
+			fmt.Fprintln(os.Stderr, "cname is required with -blue-green")
+			fs.Usage()
+		}
+	} else if *env == "" {
 		fmt.Fprintln(os.Stderr, "env is required")
 		fs.Usage()
 	}
@@ -382,6 +579,15 @@ func deployCmd(args []string) {
 		fs.Usage()
 	}
 
+	if *varsExplain {
+		vars, err := readEnvVars(*dir, *env, *app, false)
+		if err != nil {
+			log.Fatal(err)
+		}
+		explainEnvVars(*dir, vars)
+		return
+	}
+
 	if *bucket == "" {
 		fmt.Fprintln(os.Stderr, "bucket is required")
 		fs.Usage()
@@ -401,80 +607,313 @@ func deployCmd(args []string) {
 		fs.Usage()
 	}
 
-	if err := deploy(*dir, *env, *app, bucketURL, *label); err != nil {
+	if *protected != "" {
+		opts.protected = strings.Split(*protected, ",")
+	}
+	if *blueGreen {
+		if err := blueGreenDeploy(*dir, *app, bucketURL, *label, &bg, &opts); err != nil {
+			log.Fatal("blue/green deploy failed: ", err)
+		}
+	} else if err := deploy(*dir, *env, *app, bucketURL, *label, &opts); err != nil {
 		log.Fatal("deploy failed: ", err)
 	}
-	fmt.Printf("Deploy initiated (took %s)\n", time.Since(t0))
+	if *dryRun {
+		fmt.Printf("Deploy planned (took %s)\n", time.Since(t0))
+	} else if opts.wait {
+		fmt.Printf("Deploy completed (took %s)\n", time.Since(t0))
+	} else {
+		fmt.Printf("Deploy initiated (took %s)\n", time.Since(t0))
+	}
 }
 
-func deploy(dir string, env, app string, bucketURL *url.URL, label string) error {
-	var buf bytes.Buffer
-	if err := bundle(dir, &buf); err != nil {
-		return fmt.Errorf("bundle failed: %s", err)
+type deployOptions struct {
+	removeMissing bool     // remove env vars not set by any layer
+	yes           bool     // don't ask for confirmation
+	protected     []string // glob patterns of protected env names
+
+	wait    bool          // wait for the deploy to finish
+	timeout time.Duration // how long to wait
+
+	// options are option settings to set along with the env vars.
+	options elasticbeanstalk.ConfigurationOptionSettings
+}
+
+func deploy(dir string, env, app string, bucketURL *url.URL, label string, opts *deployOptions) error {
+	p := &elasticbeanstalk.UpdateEnvironmentParams{
+		EnvironmentName: env,
 	}
 
-	fullLabel, err := upload(&buf, app, bucketURL, label)
+	// Get and check env vars before bundling and uploading, so that invalid
+	// vars are reported before anything is changed.
+	vars, err := readEnvVars(dir, env, app, true)
 	if err != nil {
-		return fmt.Errorf("upload failed: %s", err)
+		return err
+	}
+	for _, v := range vars {
+		if *verbose {
+			log.Printf(" - %s (from %s)", v.Name, v.Source)
+		}
+		p.AddEnv(v.Name, v.Value)
 	}
+	p.OptionSettings = append(p.OptionSettings, opts.options...)
 
-	p := &elasticbeanstalk.UpdateEnvironmentParams{
-		EnvironmentName: env,
-		VersionLabel:    fullLabel,
+	current, err := currentOptionSettings(env, app)
+	if err != nil {
+		return err
+	}
+	if opts.removeMissing {
+		removeMissingEnvVars(p, current)
 	}
 
-	// Get env vars
-	if err := setEnvVarsFromScript(dir, env, app, p); err != nil {
+	// The size limits apply to all of the environment's env vars, so check
+	// the vars it will have after the deploy, not just the deployed ones.
+	updated := p.Apply(current)
+	if err := updated.ValidateEnv(); err != nil {
 		return err
 	}
+	if err := checkEnvSchema(dir, env, updated.Environ()); err != nil {
+		return err
+	}
+
+	printEnvChanges(env, p.Changes(current))
+	if err := confirmDeploy(env, opts); err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	if err := bundle(dir, &buf); err != nil {
+		return fmt.Errorf("bundle failed: %s", err)
+	}
+
+	fullLabel, err := upload(&buf, app, bucketURL, label)
+	if err != nil {
+		return fmt.Errorf("upload failed: %s", err)
+	}
+	p.VersionLabel = fullLabel
 
 	if *verbose {
 		log.Printf("Updating environment %q to use version %q...", env, fullLabel)
 	}
 
+	t0 := time.Now()
 	if err := ebClient.UpdateEnvironment(p); err != nil {
 		return fmt.Errorf("update environment failed: %s", err)
 	}
 
+	if opts.wait && !*dryRun {
+		e, err := waitForReady(app, env, t0, opts.timeout)
+		if err != nil {
+			return err
+		}
+		if e.VersionLabel != fullLabel {
+			return fmt.Errorf("%s is running version %q, not %q (was the deploy rolled back?)", env, e.VersionLabel, fullLabel)
+		}
+	}
+
 	return nil
 }
 
-// setEnvVarsFromScript invokes the executable named `.ebc-vars` in dir to
-// obtain environment variables to set in the environment. The output is
-// expected to be of the form "FOO=BAR\nBAZ=QUX\n" (empty lines are ignored).
-func setEnvVarsFromScript(dir, env, app string, p *elasticbeanstalk.UpdateEnvironmentParams) error {
+// varsDir is the directory (relative to the directory being deployed) that
+// contains layered env var files.
+const varsDir = ".ebc/vars"
+
+// readEnvVars reads the environment variables to set in the environment
+// from each of the following layers (if they exist), with later layers
+// overriding earlier ones:
+//
+//	.ebc/vars/global.env
+//	.ebc/vars/APP.env
+//	.ebc/vars/ENV.env
+//	the output of the .ebc-vars script (or the .ebc-vars.env file)
+//
+// The variables may be in dotenv or JSON format (see package ebcvars). If
+// decrypt is true, encrypted values are decrypted with the -secrets-key
+// file.
+func readEnvVars(dir, env, app string, decrypt bool) ([]ebcvars.ResolvedVar, error) {
+	var layers []*ebcvars.Layer
+	for _, name := range []string{"global", app, env} {
+		l, err := ebcvars.ReadFile(filepath.Join(dir, varsDir, name+".env"))
+		if err != nil {
+			return nil, err
+		}
+		if l != nil && *verbose {
+			log.Printf("Read vars file %s", l.Source)
+		}
+		layers = append(layers, l)
+	}
+	l, err := readVarsScript(dir, env, app)
+	if err != nil {
+		return nil, err
+	}
+	layers = append(layers, l)
+	if decrypt {
+		for _, l := range layers {
+			if err := decryptLayer(l); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return ebcvars.Merge(layers...)
+}
+
+// readVarsScript invokes the executable named `.ebc-vars` in dir (with env
+// and app as arguments) to obtain environment variables. If there is no
+// such executable, the variables are read from the file `.ebc-vars.env` in
+// dir, if it exists.
+func readVarsScript(dir, env, app string) (*ebcvars.Layer, error) {
 	scriptFile := filepath.Join(dir, ".ebc-vars")
 	fi, err := os.Stat(scriptFile)
-	if err == nil && fi.Mode().IsRegular() {
-		if *verbose {
-			log.Printf("Running vars script %s...", scriptFile)
+	if err != nil || !fi.Mode().IsRegular() {
+		return ebcvars.ReadFile(filepath.Join(dir, ".ebc-vars.env"))
+	}
+
+	if *verbose {
+		log.Printf("Running vars script %s...", scriptFile)
+	}
+	script := exec.Command(scriptFile, env, app)
+	script.Dir = dir
+	if *verbose {
+		script.Stderr = os.Stderr
+	}
+	out, err := script.Output()
+	if err != nil {
+		return nil, fmt.Errorf("running %s: %s", scriptFile, err)
+	}
+	vars, err := ebcvars.Parse(out)
+	if err != nil {
+		return nil, fmt.Errorf("invalid env vars from %s: %s", scriptFile, err)
+	}
+	return &ebcvars.Layer{Source: scriptFile, Vars: vars}, nil
+}
+
+const varsSchemaFile = ".ebc-vars.schema"
+
+// checkEnvSchema checks vars, the env vars that env will have after the
+// deploy, against the schema in the directory's .ebc-vars.schema file, if it
+// exists.
+func checkEnvSchema(dir, env string, vars map[string]string) error {
+	schema, err := ebcvars.ReadSchemaFile(filepath.Join(dir, varsSchemaFile))
+	if err != nil || schema == nil {
+		return err
+	}
+	if *verbose {
+		log.Printf("Checking %d env vars against %s...", len(vars), varsSchemaFile)
+	}
+	return schema.Check(env, vars)
+}
+
+// removeMissingEnvVars adds the env vars that are set in current but not in
+// p to p.OptionsToRemove.
+func removeMissingEnvVars(p *elasticbeanstalk.UpdateEnvironmentParams, current elasticbeanstalk.ConfigurationOptionSettings) {
+	set := p.OptionSettings.Environ()
+	var missing []string
+	for name := range current.Environ() {
+		if _, present := set[name]; !present {
+			missing = append(missing, name)
 		}
-		script := exec.Command(scriptFile, env, app)
-		script.Dir = dir
-		if *verbose {
-			script.Stderr = os.Stderr
+	}
+	sort.Strings(missing)
+	for _, name := range missing {
+		p.RemoveEnv(name)
+	}
+}
+
+// printEnvChanges prints the names of the env vars that are added, changed,
+// and removed by changes. Values are not printed, since they may be secret.
+func printEnvChanges(env string, changes []elasticbeanstalk.OptionChange) {
+	var envChanges []elasticbeanstalk.OptionChange
+	for _, c := range changes {
+		if c.Key.Namespace == elasticbeanstalk.NamespaceApplicationEnvironment {
+			envChanges = append(envChanges, c)
 		}
-		out, err := script.Output()
-		if err != nil {
-			return fmt.Errorf("running %s: %s", scriptFile, err)
+	}
+	if len(envChanges) == 0 {
+		fmt.Printf("No env var changes for %s.\n", env)
+		return
+	}
+	fmt.Printf("Env var changes for %s:\n", env)
+	for _, c := range envChanges {
+		var sign string
+		switch c.Kind {
+		case elasticbeanstalk.Added:
+			sign = "+"
+		case elasticbeanstalk.Changed:
+			sign = "~"
+		case elasticbeanstalk.Removed:
+			sign = "-"
 		}
-		lines := bytes.Split(out, []byte("\n"))
-		for _, line := range lines {
-			if len(line) > 0 {
-				parts := bytes.SplitN(line, []byte("="), 2)
-				if len(parts) != 2 {
-					return fmt.Errorf("invalid format for env: %q", line)
-				}
-				if *verbose {
-					log.Printf(" - %s", parts[0])
-				}
-				p.AddEnv(string(parts[0]), string(parts[1]))
-			}
+		fmt.Printf("  %s %s (%s)\n", sign, c.Key.OptionName, c.Kind)
+	}
+}
+
+// isProtected reports whether env matches any of the glob patterns.
+func isProtected(env string, patterns []string) bool {
+	for _, pat := range patterns {
+		if ok, _ := path.Match(strings.TrimSpace(pat), env); ok {
+			return true
 		}
 	}
+	return false
+}
+
+// confirmDeploy asks for confirmation on stdin before deploying to a
+// protected environment. It returns an error if the deploy is not
+// confirmed.
+func confirmDeploy(env string, opts *deployOptions) error {
+	if !isProtected(env, opts.protected) || opts.yes || *dryRun {
+		return nil
+	}
+	if !isTerminal(os.Stdin) {
+		return fmt.Errorf("refusing to deploy to protected environment %s without confirmation (use -yes to skip confirmation)", env)
+	}
+	if !askYesNo(fmt.Sprintf("Deploy to protected environment %s?", env)) {
+		return fmt.Errorf("deploy to %s not confirmed", env)
+	}
 	return nil
 }
 
+// askYesNo prints question and reports whether the answer read from stdin
+// is yes.
+func askYesNo(question string) bool {
+	fmt.Printf("%s [y/N] ", question)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true
+	}
+	return false
+}
+
+// explainEnvVars prints the layer that set each variable's final value (and
+// the layers it overrode). Values are not printed, since they may be
+// secret, but encrypted values are marked as such.
+func explainEnvVars(dir string, vars []ebcvars.ResolvedVar) {
+	rel := func(source string) string {
+		if r, err := filepath.Rel(dir, source); err == nil {
+			return r
+		}
+		return source
+	}
+	if len(vars) == 0 {
+		fmt.Println("No env vars are set by any layer.")
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tSET BY\tENCRYPTED\tOVERRIDES")
+	for _, v := range vars {
+		overridden := make([]string, len(v.Overridden))
+		for i, s := range v.Overridden {
+			overridden[i] = rel(s)
+		}
+		encrypted := ""
+		if ebcvars.IsEncrypted(v.Value) {
+			encrypted = "yes"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", v.Name, rel(v.Source), encrypted, strings.Join(overridden, ", "))
+	}
+	w.Flush()
+}
+
 // makeBundleObjectURL appends successive numeric prefixes to label until it
 // finds a URL that doesn't refer to an existing object.
 func makeBundleObjectURL(bucketURL *url.URL, label string) (*url.URL, string, error) {
@@ -0,0 +1,424 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/sqs/go-elasticbeanstalk/ebcvars"
+	"github.com/sqs/go-elasticbeanstalk/elasticbeanstalk"
+)
+
+func envCmd(args []string) {
+	fs := flag.NewFlagSet("env", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: ebc env list|set|unset|pull|copy [OPTS] ARGS...\n")
+		fmt.Fprintln(os.Stderr)
+		fmt.Fprintln(os.Stderr, "Lists, changes, or copies an environment's env vars without deploying a new version.")
+		fmt.Fprintln(os.Stderr)
+		fmt.Fprintln(os.Stderr, "The subcommands are:")
+		fmt.Fprintln(os.Stderr)
+		fmt.Fprintln(os.Stderr, "\tlist [NAME...]\t lists the environment's env vars (with values redacted unless -show-values is given)")
+		fmt.Fprintln(os.Stderr, "\tset NAME=VALUE...\t sets env vars")
+		fmt.Fprintln(os.Stderr, "\tunset NAME...\t unsets env vars")
+		fmt.Fprintln(os.Stderr, "\tpull\t writes the environment's env vars to a local dotenv file")
+		fmt.Fprintln(os.Stderr, "\tcopy [NAME...]\t copies env vars from one environment to another")
+		fmt.Fprintln(os.Stderr)
+		os.Exit(1)
+	}
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		fs.Usage()
+	}
+
+	switch subcmd, args := fs.Arg(0), fs.Args()[1:]; subcmd {
+	case "list":
+		envListCmd(args)
+	case "set":
+		envSetCmd(args)
+	case "unset":
+		envUnsetCmd(args)
+	case "pull":
+		envPullCmd(args)
+	case "copy":
+		envCopyCmd(args)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown env subcommand %q\n", subcmd)
+		fs.Usage()
+	}
+}
+
+// envFlags defines the -env and -app flags on fs, defaulting to the values
+// in the directory's EB config.
+func envFlags(fs *flag.FlagSet) (env, app *string) {
+	df := envDefaults()
+	env = fs.String("env", df.env, "EB environment name")
+	app = fs.String("app", df.app, "EB application name")
+	return env, app
+}
+
+// appFlag defines only the -app flag on fs, for subcommands that take
+// environment names in other flags.
+func appFlag(fs *flag.FlagSet) *string {
+	return fs.String("app", envDefaults().app, "EB application name")
+}
+
+// envDefaults returns the defaults in the directory's EB config, or empty
+// defaults if it can't be read.
+func envDefaults() *defaults {
+	df, err := readDefaults(*dir)
+	if err != nil {
+		if *verbose {
+			log.Printf("Warning: couldn't read defaults: %s. Flag values must be explicitly specified.", err)
+		}
+		df = new(defaults)
+	}
+	return df
+}
+
+// checkEnvFlags exits with usage information if the -env or -app flag
+// defined by envFlags is empty.
+func checkEnvFlags(fs *flag.FlagSet, env, app string) {
+	if env == "" {
+		fmt.Fprintln(os.Stderr, "env is required")
+		fs.Usage()
+	}
+
+	if app == "" {
+		fmt.Fprintln(os.Stderr, "app is required")
+		fs.Usage()
+	}
+}
+
+func envListCmd(args []string) {
+	fs := flag.NewFlagSet("env list", flag.ExitOnError)
+	env, app := envFlags(fs)
+	showValues := fs.Bool("show-values", false, "show env var values (which may be secret) instead of redacting them")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: ebc env list [OPTS] [NAME...]\n")
+		fmt.Fprintln(os.Stderr)
+		fmt.Fprintln(os.Stderr, "Lists an environment's env vars (or only the named ones) in dotenv format. Values are redacted unless -show-values is given.")
+		fmt.Fprintln(os.Stderr)
+		fs.PrintDefaults()
+		fmt.Fprintln(os.Stderr)
+		os.Exit(1)
+	}
+	fs.Parse(args)
+	checkEnvFlags(fs, *env, *app)
+
+	current, err := currentOptionSettings(*env, *app)
+	if err != nil {
+		log.Fatal(err)
+	}
+	vars := ebcvars.FromMap(current.Environ())
+	if fs.NArg() > 0 {
+		environ := vars.Map()
+		vars = nil
+		for _, name := range fs.Args() {
+			value, present := environ[name]
+			if !present {
+				log.Printf("Warning: %s is not set in %s", name, *env)
+				continue
+			}
+			vars = append(vars, ebcvars.Var{Name: name, Value: value})
+		}
+	}
+	if !*showValues {
+		for i := range vars {
+			vars[i].Value = "REDACTED"
+		}
+	}
+	os.Stdout.Write(ebcvars.Format(vars))
+}
+
+func envPullCmd(args []string) {
+	fs := flag.NewFlagSet("env pull", flag.ExitOnError)
+	env, app := envFlags(fs)
+	out := fs.String("out", "", "file to write (default .env.ENV)")
+	force := fs.Bool("force", false, "overwrite the -out file if it exists")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: ebc env pull [OPTS]\n")
+		fmt.Fprintln(os.Stderr)
+		fmt.Fprintln(os.Stderr, "Writes an environment's env vars (including their values, which may be secret) to a dotenv file, for local debugging.")
+		fmt.Fprintln(os.Stderr)
+		fs.PrintDefaults()
+		fmt.Fprintln(os.Stderr)
+		os.Exit(1)
+	}
+	fs.Parse(args)
+	checkEnvFlags(fs, *env, *app)
+
+	if fs.NArg() != 0 {
+		fmt.Fprintln(os.Stderr, "no positional args")
+		fs.Usage()
+	}
+
+	if *out == "" {
+		*out = ".env." + *env
+	}
+
+	settings, err := ebClient.DescribeConfigurationSettings(&elasticbeanstalk.DescribeConfigurationSettingsParams{
+		ApplicationName: *app,
+		EnvironmentName: *env,
+	})
+	if err != nil {
+		log.Fatalf("getting current configuration of %s: %s", *env, err)
+	}
+	vars := ebcvars.FromMap(settings.Environ())
+
+	// The values may be secret, so only the owner may read the file.
+	flags := os.O_WRONLY | os.O_CREATE | os.O_EXCL
+	if *force {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	}
+	f, err := os.OpenFile(*out, flags, 0600)
+	if os.IsExist(err) {
+		log.Fatalf("%s already exists (use -force to overwrite it)", *out)
+	} else if err != nil {
+		log.Fatal(err)
+	}
+	// An existing file keeps its permissions when it's overwritten, so
+	// restrict them before writing.
+	if err := f.Chmod(0600); err != nil {
+		f.Close()
+		log.Fatal(err)
+	}
+	if _, err := f.Write(ebcvars.Format(vars)); err != nil {
+		f.Close()
+		log.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("Wrote %d env vars from %s to %s\n", len(vars), *env, *out)
+}
+
+func envSetCmd(args []string) {
+	fs := flag.NewFlagSet("env set", flag.ExitOnError)
+	env, app := envFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: ebc env set [OPTS] NAME=VALUE...\n")
+		fmt.Fprintln(os.Stderr)
+		fmt.Fprintln(os.Stderr, "Sets env vars in an environment, without deploying a new version. Encrypted values (ENC[...]) are decrypted with the -secrets-key file.")
+		fmt.Fprintln(os.Stderr)
+		fs.PrintDefaults()
+		fmt.Fprintln(os.Stderr)
+		os.Exit(1)
+	}
+	fs.Parse(args)
+	checkEnvFlags(fs, *env, *app)
+
+	if fs.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "at least one NAME=VALUE is required")
+		fs.Usage()
+	}
+
+	p := &elasticbeanstalk.UpdateEnvironmentParams{EnvironmentName: *env}
+	var id *ebcvars.Identity
+	for _, arg := range fs.Args() {
+		name, value, ok := strings.Cut(arg, "=")
+		if !ok {
+			fmt.Fprintf(os.Stderr, "invalid argument %q (must be NAME=VALUE)\n", arg)
+			fs.Usage()
+		}
+		if ebcvars.IsEncrypted(value) {
+			var err error
+			if id == nil {
+				if id, err = readSecretsKey(); err != nil {
+					log.Fatalf("%s has an encrypted value, but the secrets key couldn't be read: %s", name, err)
+				}
+			}
+			if value, err = id.Decrypt(name, value); err != nil {
+				log.Fatalf("%s: %s", name, err)
+			}
+		}
+		p.AddEnv(name, value)
+	}
+	if err := p.ValidateEnv(); err != nil {
+		log.Fatal(err)
+	}
+
+	if err := updateEnvVars(p, *app, nil); err != nil {
+		log.Fatal("setting env vars failed: ", err)
+	}
+}
+
+func envCopyCmd(args []string) {
+	fs := flag.NewFlagSet("env copy", flag.ExitOnError)
+	app := appFlag(fs)
+	from := fs.String("from", "", "EB environment name to copy env vars from")
+	to := fs.String("to", "", "EB environment name to copy env vars to")
+	removeMissing := fs.Bool("remove-missing", false, "also unset env vars in -to that aren't set in -from")
+	yes := fs.Bool("yes", false, "don't ask for confirmation before changing -to")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: ebc env copy [OPTS] -from ENV -to ENV [NAME...]\n")
+		fmt.Fprintln(os.Stderr)
+		fmt.Fprintln(os.Stderr, "Copies env vars (all of them, or only the named ones) from one environment to another, without deploying a new version. The changes are shown and must be confirmed before they are made.")
+		fmt.Fprintln(os.Stderr)
+		fs.PrintDefaults()
+		fmt.Fprintln(os.Stderr)
+		os.Exit(1)
+	}
+	fs.Parse(args)
+
+	if *from == "" {
+		fmt.Fprintln(os.Stderr, "from is required")
+		fs.Usage()
+	}
+
+	if *to == "" {
+		fmt.Fprintln(os.Stderr, "to is required")
+		fs.Usage()
+	}
+
+	if *from == *to {
+		fmt.Fprintln(os.Stderr, "from and to must be different environments")
+		fs.Usage()
+	}
+
+	if *app == "" {
+		fmt.Fprintln(os.Stderr, "app is required")
+		fs.Usage()
+	}
+
+	if *removeMissing && fs.NArg() != 0 {
+		fmt.Fprintln(os.Stderr, "-remove-missing can't be used with NAME args")
+		fs.Usage()
+	}
+
+	src, err := currentOptionSettings(*from, *app)
+	if err != nil {
+		log.Fatal(err)
+	}
+	vars := ebcvars.FromMap(src.Environ())
+	if fs.NArg() > 0 {
+		environ := vars.Map()
+		vars = nil
+		for _, name := range fs.Args() {
+			value, present := environ[name]
+			if !present {
+				log.Fatalf("%s is not set in %s", name, *from)
+			}
+			vars = append(vars, ebcvars.Var{Name: name, Value: value})
+		}
+	}
+
+	p := &elasticbeanstalk.UpdateEnvironmentParams{EnvironmentName: *to}
+	for _, v := range vars {
+		p.AddEnv(v.Name, v.Value)
+	}
+	if *removeMissing {
+		current, err := currentOptionSettings(*to, *app)
+		if err != nil {
+			log.Fatal(err)
+		}
+		removeMissingEnvVars(p, current)
+	}
+
+	confirm := func() error {
+		if *yes || *dryRun {
+			return nil
+		}
+		if !isTerminal(os.Stdin) {
+			return fmt.Errorf("refusing to change env vars of %s without confirmation (use -yes to skip confirmation)", *to)
+		}
+		if !askYesNo(fmt.Sprintf("Copy env vars from %s to %s?", *from, *to)) {
+			return fmt.Errorf("copy to %s not confirmed", *to)
+		}
+		return nil
+	}
+	if err := updateEnvVars(p, *app, confirm); err != nil {
+		log.Fatal("copying env vars failed: ", err)
+	}
+}
+
+func envUnsetCmd(args []string) {
+	fs := flag.NewFlagSet("env unset", flag.ExitOnError)
+	env, app := envFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: ebc env unset [OPTS] NAME...\n")
+		fmt.Fprintln(os.Stderr)
+		fmt.Fprintln(os.Stderr, "Unsets env vars in an environment, without deploying a new version.")
+		fmt.Fprintln(os.Stderr)
+		fs.PrintDefaults()
+		fmt.Fprintln(os.Stderr)
+		os.Exit(1)
+	}
+	fs.Parse(args)
+	checkEnvFlags(fs, *env, *app)
+
+	if fs.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "at least one NAME is required")
+		fs.Usage()
+	}
+
+	p := &elasticbeanstalk.UpdateEnvironmentParams{EnvironmentName: *env}
+	for _, name := range fs.Args() {
+		p.RemoveEnv(name)
+	}
+
+	if err := updateEnvVars(p, *app, nil); err != nil {
+		log.Fatal("unsetting env vars failed: ", err)
+	}
+}
+
+// currentOptionSettings returns the option settings of the environment's
+// current configuration.
+func currentOptionSettings(env, app string) (elasticbeanstalk.ConfigurationOptionSettings, error) {
+	settings, err := ebClient.DescribeConfigurationSettings(&elasticbeanstalk.DescribeConfigurationSettingsParams{
+		ApplicationName: app,
+		EnvironmentName: env,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("getting current configuration of %s: %s", env, err)
+	}
+	return settings.OptionSettings(elasticbeanstalk.Overwrite), nil
+}
+
+// updateEnvVars updates the environment's env vars as specified by p, which
+// only sets OptionSettings and OptionsToRemove (and not VersionLabel), so the
+// deployed version is unchanged. The resulting env vars are checked against
+// the schema file (if any), and the names of changed env vars are printed.
+// If confirm is non-nil, it is called after the changes are printed, and
+// the update is only made if it returns nil.
+func updateEnvVars(p *elasticbeanstalk.UpdateEnvironmentParams, app string, confirm func() error) error {
+	current, err := currentOptionSettings(p.EnvironmentName, app)
+	if err != nil {
+		return err
+	}
+	for _, o := range p.OptionsToRemove {
+		if _, present := current.Environ()[o.OptionName]; !present {
+			log.Printf("Warning: %s is not set in %s", o.OptionName, p.EnvironmentName)
+		}
+	}
+	// Adding a single var can push an environment over the total size limit,
+	// so validate all of its vars after the update.
+	updated := p.Apply(current)
+	if err := updated.ValidateEnv(); err != nil {
+		return err
+	}
+	if err := checkEnvSchema(*dir, p.EnvironmentName, updated.Environ()); err != nil {
+		return err
+	}
+
+	changes := p.Changes(current)
+	printEnvChanges(p.EnvironmentName, changes)
+	if len(changes) == 0 {
+		return nil
+	}
+	if confirm != nil {
+		if err := confirm(); err != nil {
+			return err
+		}
+	}
+
+	if err := ebClient.UpdateEnvironment(p); err != nil {
+		return fmt.Errorf("update environment failed: %s", err)
+	}
+	if !*dryRun {
+		fmt.Printf("Env var update of %s initiated\n", p.EnvironmentName)
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/sqs/go-elasticbeanstalk/elasticbeanstalk"
+)
+
+var severities = []string{"TRACE", "DEBUG", "INFO", "WARN", "ERROR", "FATAL"}
+
+func eventsCmd(args []string) {
+	df, err := readDefaults(*dir)
+	if err != nil {
+		if *verbose {
+			log.Printf("Warning: couldn't read defaults: %s. Flag values must be explicitly specified.", err)
+		}
+		df = new(defaults)
+	}
+
+	fs := flag.NewFlagSet("events", flag.ExitOnError)
+	env := fs.String("env", df.env, "EB environment name")
+	app := fs.String("app", df.app, "EB application name")
+	follow := fs.Bool("f", false, "keep printing new events as they occur, until interrupted")
+	since := fs.String("since", "1h", "show events since this long ago (such as 30m) or this time (RFC 3339, such as 2014-02-28T15:04:05Z)")
+	severity := fs.String("severity", "", "minimum severity of events to show ("+strings.Join(severities, ", ")+")")
+	interval := fs.Duration("interval", 5*time.Second, "how often to check for new events with -f")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: ebc events [OPTS]\n")
+		fmt.Fprintln(os.Stderr)
+		fmt.Fprintln(os.Stderr, "Prints an environment's events, oldest first.")
+		fmt.Fprintln(os.Stderr)
+		fs.PrintDefaults()
+		fmt.Fprintln(os.Stderr)
+		os.Exit(1)
+	}
+	fs.Parse(args)
+
+	if *env == "" {
+		fmt.Fprintln(os.Stderr, "env is required")
+		fs.Usage()
+	}
+
+	if *app == "" {
+		fmt.Fprintln(os.Stderr, "app is required")
+		fs.Usage()
+	}
+
+	startTime, err := parseSince(*since)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		fs.Usage()
+	}
+
+	*severity = strings.ToUpper(*severity)
+	if *severity != "" && !contains(severities, *severity) {
+		fmt.Fprintf(os.Stderr, "invalid severity %q\n", *severity)
+		fs.Usage()
+	}
+
+	if fs.NArg() != 0 {
+		fmt.Fprintln(os.Stderr, "no positional args")
+		fs.Usage()
+	}
+
+	p := &elasticbeanstalk.EventPoller{
+		API: ebClient,
+		Params: elasticbeanstalk.DescribeEventsParams{
+			ApplicationName: *app,
+			EnvironmentName: *env,
+			Severity:        *severity,
+			StartTime:       startTime,
+		},
+	}
+	evs, err := p.Poll()
+	if err != nil {
+		log.Fatal("describing events failed: ", err)
+	}
+	for _, ev := range evs {
+		printEvent(ev)
+	}
+
+	for *follow {
+		time.Sleep(*interval)
+		evs, err := p.Poll()
+		if err != nil {
+			// Keep following through transient errors.
+			log.Printf("Warning: describing events failed: %s", err)
+			continue
+		}
+		for _, ev := range evs {
+			printEvent(ev)
+		}
+	}
+}
+
+// parseSince parses s as a duration before now (such as "30m") or an RFC
+// 3339 time.
+func parseSince(s string) (time.Time, error) {
+	if d, err := time.ParseDuration(s); err == nil {
+		return time.Now().Add(-d).UTC(), nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid -since %q (must be a duration or an RFC 3339 time)", s)
+	}
+	return t.UTC(), nil
+}
+
+func contains(list []string, s string) bool {
+	for _, x := range list {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/sqs/go-elasticbeanstalk/ebextensions"
+)
+
+func lintCmd(args []string) {
+	fs := flag.NewFlagSet("lint", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: ebc lint\n")
+		fmt.Fprintln(os.Stderr)
+		fmt.Fprintf(os.Stderr, "Checks the %s/*.config files in a directory (specified with -dir=DIR) for structural errors, such as misspelled keys and values of the wrong type.\n", ebextensions.Dir)
+		fmt.Fprintln(os.Stderr)
+		fs.PrintDefaults()
+		fmt.Fprintln(os.Stderr)
+		os.Exit(1)
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 0 {
+		fmt.Fprintln(os.Stderr, "no positional args")
+		fs.Usage()
+	}
+
+	configs, err := ebextensions.ParseDir(*dir)
+	if errs, ok := err.(ebextensions.ErrorList); ok {
+		for _, e := range errs {
+			fmt.Fprintln(os.Stderr, e)
+		}
+		log.Fatalf("lint failed: %d error(s)", len(errs))
+	} else if err != nil {
+		log.Fatal("lint failed: ", err)
+	}
+
+	if *verbose {
+		// Env var values may be secret, so they aren't printed.
+		for _, o := range ebextensions.MergedOptionSettings(configs).Redacted() {
+			log.Printf(" - %s = %q", o.Key(), o.Value)
+		}
+	}
+	fmt.Printf("No errors found in %d config file(s)\n", len(configs))
+}
@@ -0,0 +1,281 @@
+package main
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sqs/go-elasticbeanstalk/elasticbeanstalk"
+)
+
+func logsCmd(args []string) {
+	df, err := readDefaults(*dir)
+	if err != nil {
+		if *verbose {
+			log.Printf("Warning: couldn't read defaults: %s. Flag values must be explicitly specified.", err)
+		}
+		df = new(defaults)
+	}
+
+	fs := flag.NewFlagSet("logs", flag.ExitOnError)
+	env := fs.String("env", df.env, "EB environment name")
+	app := fs.String("app", df.app, "EB application name")
+	bundle := fs.Bool("bundle", false, "download each instance's full log bundle instead of the last lines of its logs")
+	outDir := fs.String("out", "logs", "directory to unpack log bundles into (with -bundle); each instance's logs go in a subdirectory named after its instance ID")
+	instance := fs.String("instance", "", "only show logs from this instance ID")
+	grep := fs.String("grep", "", "only print log lines matching this regexp")
+	timeout := fs.Duration("timeout", 2*time.Minute, "how long to wait for the logs to be collected")
+	interval := fs.Duration("interval", 5*time.Second, "how often to check whether the logs have been collected")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: ebc logs [OPTS]\n")
+		fmt.Fprintln(os.Stderr)
+		fmt.Fprintln(os.Stderr, "Collects the logs of an environment's instances and prints the last lines of")
+		fmt.Fprintln(os.Stderr, "each (grouped by instance), or with -bundle, downloads and unpacks all of them.")
+		fmt.Fprintln(os.Stderr)
+		fs.PrintDefaults()
+		fmt.Fprintln(os.Stderr)
+		os.Exit(1)
+	}
+	fs.Parse(args)
+
+	if *env == "" {
+		fmt.Fprintln(os.Stderr, "env is required")
+		fs.Usage()
+	}
+
+	if *app == "" {
+		fmt.Fprintln(os.Stderr, "app is required")
+		fs.Usage()
+	}
+
+	var pattern *regexp.Regexp
+	if *grep != "" {
+		pattern, err = regexp.Compile(*grep)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "invalid -grep: %s\n", err)
+			fs.Usage()
+		}
+	}
+
+	if fs.NArg() != 0 {
+		fmt.Fprintln(os.Stderr, "no positional args")
+		fs.Usage()
+	}
+
+	infoType := elasticbeanstalk.InfoTail
+	if *bundle {
+		infoType = elasticbeanstalk.InfoBundle
+	}
+
+	if *dryRun {
+		// Requesting info makes the instances collect their logs, so the
+		// request is only recorded, and there are no new logs to wait for.
+		if err := ebClient.RequestEnvironmentInfo(&elasticbeanstalk.RequestEnvironmentInfoParams{EnvironmentName: *env, InfoType: infoType}); err != nil {
+			log.Fatal("requesting environment info failed: ", err)
+		}
+		log.Printf("Dry run: not collecting logs from %s", *env)
+		return
+	}
+
+	info, err := collectEnvironmentInfo(*env, infoType, *timeout, *interval)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *instance != "" {
+		var matched []*elasticbeanstalk.EnvironmentInfoDescription
+		for _, in := range info {
+			if in.Ec2InstanceId == *instance {
+				matched = append(matched, in)
+			}
+		}
+		if len(matched) == 0 {
+			log.Fatalf("no logs from instance %q", *instance)
+		}
+		info = matched
+	}
+
+	for _, in := range info {
+		data, err := fetchLogs(in.Message)
+		if err != nil {
+			log.Fatalf("downloading logs from instance %s failed: %s", in.Ec2InstanceId, err)
+		}
+		if *bundle {
+			n, err := unpackLogBundle(data, filepath.Join(*outDir, in.Ec2InstanceId), in.Ec2InstanceId, pattern)
+			if err != nil {
+				log.Fatalf("unpacking logs from instance %s failed: %s", in.Ec2InstanceId, err)
+			}
+			log.Printf("Unpacked %d log files from instance %s to %s", n, in.Ec2InstanceId, filepath.Join(*outDir, in.Ec2InstanceId))
+		} else {
+			fmt.Printf("==> %s <==\n", in.Ec2InstanceId)
+			printMatchingLines(bytes.NewReader(data), "", pattern)
+			fmt.Println()
+		}
+	}
+}
+
+// collectEnvironmentInfo requests info of the given type from each instance
+// of the environment and waits until it has been collected. It returns the
+// newly collected info for each instance, ordered by instance ID.
+//
+// Previously collected info remains available from RetrieveEnvironmentInfo,
+// so new info is told apart by comparing timestamps with the info that was
+// available before the request (rather than with the local clock, which may
+// be skewed).
+func collectEnvironmentInfo(env, infoType string, timeout, interval time.Duration) ([]*elasticbeanstalk.EnvironmentInfoDescription, error) {
+	retrieve := func() (map[string]*elasticbeanstalk.EnvironmentInfoDescription, error) {
+		info, err := ebClient.RetrieveEnvironmentInfo(&elasticbeanstalk.RetrieveEnvironmentInfoParams{EnvironmentName: env, InfoType: infoType})
+		if err != nil {
+			return nil, fmt.Errorf("retrieving environment info failed: %s", err)
+		}
+		return latestEnvironmentInfo(info), nil
+	}
+
+	prev, err := retrieve()
+	if err != nil {
+		return nil, err
+	}
+	if err := ebClient.RequestEnvironmentInfo(&elasticbeanstalk.RequestEnvironmentInfoParams{EnvironmentName: env, InfoType: infoType}); err != nil {
+		return nil, fmt.Errorf("requesting environment info failed: %s", err)
+	}
+	if *verbose {
+		log.Printf("Requested %s logs from environment %q; waiting for them to be collected...", infoType, env)
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		cur, err := retrieve()
+		if err != nil {
+			return nil, err
+		}
+		var fresh []*elasticbeanstalk.EnvironmentInfoDescription
+		for id, in := range cur {
+			if p, present := prev[id]; !present || in.SampleTimestamp.After(p.SampleTimestamp.Time) {
+				fresh = append(fresh, in)
+			}
+		}
+		sort.Slice(fresh, func(i, j int) bool { return fresh[i].Ec2InstanceId < fresh[j].Ec2InstanceId })
+
+		// Instances report their info independently, so wait for every
+		// instance that reported before, unless time is up.
+		if len(fresh) > 0 && len(fresh) >= len(prev) {
+			return fresh, nil
+		}
+		if time.Now().After(deadline) {
+			if len(fresh) > 0 {
+				log.Printf("Warning: timed out waiting for logs from all instances; showing logs from %d of them", len(fresh))
+				return fresh, nil
+			}
+			return nil, fmt.Errorf("timed out after %s waiting for %s logs to be collected", timeout, infoType)
+		}
+		time.Sleep(interval)
+	}
+}
+
+// latestEnvironmentInfo returns the most recently collected info for each
+// instance, keyed on instance ID.
+func latestEnvironmentInfo(info []*elasticbeanstalk.EnvironmentInfoDescription) map[string]*elasticbeanstalk.EnvironmentInfoDescription {
+	latest := make(map[string]*elasticbeanstalk.EnvironmentInfoDescription, len(info))
+	for _, in := range info {
+		if l, present := latest[in.Ec2InstanceId]; !present || in.SampleTimestamp.After(l.SampleTimestamp.Time) {
+			latest[in.Ec2InstanceId] = in
+		}
+	}
+	return latest
+}
+
+// fetchLogs downloads logs from the presigned URL returned by
+// RetrieveEnvironmentInfo.
+func fetchLogs(url string) ([]byte, error) {
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP %s", resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// unpackLogBundle unpacks the zip archive data into dir and returns the
+// number of files unpacked. If pattern is non-nil, the lines of each file
+// that match it are also printed, prefixed with the instance ID and path.
+func unpackLogBundle(data []byte, dir, instanceID string, pattern *regexp.Regexp) (int, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		// Don't let entries (such as "../x") escape dir.
+		name := filepath.FromSlash(f.Name)
+		if !filepath.IsLocal(name) {
+			return n, fmt.Errorf("invalid file name %q in log bundle", f.Name)
+		}
+		dst := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+			return n, err
+		}
+		if err := unpackFile(f, dst); err != nil {
+			return n, err
+		}
+		n++
+
+		if pattern != nil {
+			r, err := os.Open(dst)
+			if err != nil {
+				return n, err
+			}
+			printMatchingLines(r, instanceID+"/"+f.Name+":", pattern)
+			r.Close()
+		}
+	}
+	return n, nil
+}
+
+func unpackFile(f *zip.File, dst string) error {
+	r, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	w, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(w, r); err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+// printMatchingLines prints each line read from r that matches pattern (or
+// every line, if pattern is nil), prefixed with prefix.
+func printMatchingLines(r io.Reader, prefix string, pattern *regexp.Regexp) {
+	s := bufio.NewScanner(r)
+	s.Buffer(nil, 1024*1024)
+	for s.Scan() {
+		line := s.Text()
+		if pattern == nil || pattern.MatchString(line) {
+			fmt.Println(prefix + strings.TrimRight(line, "\r"))
+		}
+	}
+	if err := s.Err(); err != nil {
+		log.Printf("Warning: reading logs failed: %s", err)
+	}
+}
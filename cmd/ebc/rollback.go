@@ -0,0 +1,131 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/sqs/go-elasticbeanstalk/elasticbeanstalk"
+)
+
+func rollbackCmd(args []string) {
+	df, err := readDefaults(*dir)
+	if err != nil {
+		if *verbose {
+			log.Printf("Warning: couldn't read defaults: %s. Flag values must be explicitly specified.", err)
+		}
+		df = new(defaults)
+	}
+
+	fs := flag.NewFlagSet("rollback", flag.ExitOnError)
+	env := fs.String("env", df.env, "EB environment name")
+	app := fs.String("app", df.app, "EB application name")
+	to := fs.String("to", "", "label of the version to roll back to (default: the version successfully deployed before the current one)")
+	timeout := fs.Duration("timeout", 15*time.Minute, "how long to wait for the environment to become Ready")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: ebc rollback [OPTS]\n")
+		fmt.Fprintln(os.Stderr)
+		fmt.Fprintln(os.Stderr, "Redeploys a previous application version to an environment (without bundling or uploading anything) and waits for the environment to become Ready. By default, the previous version is found from the environment's events.")
+		fmt.Fprintln(os.Stderr)
+		fs.PrintDefaults()
+		fmt.Fprintln(os.Stderr)
+		os.Exit(1)
+	}
+	fs.Parse(args)
+
+	if *env == "" {
+		fmt.Fprintln(os.Stderr, "env is required")
+		fs.Usage()
+	}
+
+	if *app == "" {
+		fmt.Fprintln(os.Stderr, "app is required")
+		fs.Usage()
+	}
+
+	if fs.NArg() != 0 {
+		fmt.Fprintln(os.Stderr, "no positional args")
+		fs.Usage()
+	}
+
+	envs, err := ebClient.DescribeEnvironments(&elasticbeanstalk.DescribeEnvironmentsParams{ApplicationName: *app, EnvironmentName: *env})
+	if err != nil {
+		log.Fatal("describing environment failed: ", err)
+	}
+	current := elasticbeanstalk.CurrentEnvironment(envs)
+	if current == nil {
+		log.Fatalf("environment %q not found in application %q", *env, *app)
+	}
+	if current.Status != "Ready" {
+		log.Fatalf("environment %s is %s; it must be Ready to roll back", *env, current.Status)
+	}
+
+	label := *to
+	if label == "" {
+		label, err = previousVersion(*app, *env, current.VersionLabel)
+		if err != nil {
+			log.Fatal(err)
+		}
+	} else if exists, err := versionExists(*app, label); err != nil {
+		log.Fatal(err)
+	} else if !exists {
+		log.Fatalf("version %q of application %q not found", label, *app)
+	}
+	if label == current.VersionLabel {
+		log.Fatalf("environment %s is already running version %q", *env, label)
+	}
+
+	fmt.Printf("Rolling back %s from version %q to %q\n", *env, current.VersionLabel, label)
+	t0 := time.Now()
+	if err := ebClient.UpdateEnvironment(&elasticbeanstalk.UpdateEnvironmentParams{EnvironmentName: *env, VersionLabel: label}); err != nil {
+		log.Fatal("update environment failed: ", err)
+	}
+	if *dryRun {
+		return
+	}
+	e, err := waitForReady(*app, *env, t0, *timeout)
+	if err != nil {
+		log.Fatal("rollback failed: ", err)
+	}
+	if e.VersionLabel != label {
+		log.Fatalf("rollback failed: %s is running version %q, not %q", *env, e.VersionLabel, label)
+	}
+}
+
+// previousVersion returns the label of the most recent version, other than
+// current, that was successfully deployed to the environment (according to
+// its events) and still exists.
+func previousVersion(app, env, current string) (string, error) {
+	labels, err := elasticbeanstalk.VersionHistory(ebClient, elasticbeanstalk.DescribeEventsParams{ApplicationName: app, EnvironmentName: env})
+	if err != nil {
+		return "", fmt.Errorf("describing events failed: %s", err)
+	}
+	for _, label := range labels {
+		if label == current {
+			continue
+		}
+		exists, err := versionExists(app, label)
+		if err != nil {
+			return "", err
+		}
+		if exists {
+			return label, nil
+		}
+		if *verbose {
+			log.Printf("Version %q was deployed before, but it no longer exists. Skipping.", label)
+		}
+	}
+	return "", fmt.Errorf("no previous version of environment %s found in its events (use -to to specify one)", env)
+}
+
+// versionExists reports whether the application has a version with the
+// given label.
+func versionExists(app, label string) (bool, error) {
+	res, err := ebClient.DescribeApplicationVersions(&elasticbeanstalk.DescribeApplicationVersionsParams{ApplicationName: app, VersionLabels: []string{label}})
+	if err != nil {
+		return false, fmt.Errorf("describing application versions failed: %s", err)
+	}
+	return res != nil && len(res.ApplicationVersions) > 0, nil
+}
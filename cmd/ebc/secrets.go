@@ -0,0 +1,283 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sqs/go-elasticbeanstalk/ebcvars"
+)
+
+var secretsKey = flag.String("secrets-key", defaultSecretsKey(), "key file used to decrypt encrypted env var values (default from $EBC_SECRETS_KEY)")
+
+// secretsPubFile is the file (relative to the directory being deployed) that
+// contains the public key that secret values are encrypted to.
+const secretsPubFile = ".ebc/secrets.pub"
+
+func defaultSecretsKey() string {
+	if f := os.Getenv("EBC_SECRETS_KEY"); f != "" {
+		return f
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		return filepath.Join(home, ".ebc", "secrets.key")
+	}
+	return ""
+}
+
+// readSecretsKey reads the identity from the -secrets-key file.
+func readSecretsKey() (*ebcvars.Identity, error) {
+	if *secretsKey == "" {
+		return nil, fmt.Errorf("no secrets key file (set -secrets-key or $EBC_SECRETS_KEY)")
+	}
+	return ebcvars.ReadIdentityFile(*secretsKey)
+}
+
+// decryptLayer decrypts the encrypted values in l (if any) with the
+// -secrets-key identity.
+func decryptLayer(l *ebcvars.Layer) error {
+	if l == nil || !l.Vars.Encrypted() {
+		return nil
+	}
+	id, err := readSecretsKey()
+	if err != nil {
+		return fmt.Errorf("%s contains encrypted values, but the secrets key couldn't be read: %s", l.Source, err)
+	}
+	l.Vars, err = l.Vars.Decrypt(id)
+	if err != nil {
+		return fmt.Errorf("%s: %s", l.Source, err)
+	}
+	return nil
+}
+
+func secretsCmd(args []string) {
+	fs := flag.NewFlagSet("secrets", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: ebc secrets keygen|encrypt|decrypt|rotate [OPTS] ARGS...\n")
+		fmt.Fprintln(os.Stderr)
+		fmt.Fprintln(os.Stderr, "Manages encrypted values in env var files. An encrypted value (ENC[...]) may be used as the value of any variable, and it is decrypted with the -secrets-key file when deploying.")
+		fmt.Fprintln(os.Stderr)
+		fmt.Fprintln(os.Stderr, "The subcommands are:")
+		fmt.Fprintln(os.Stderr)
+		fmt.Fprintln(os.Stderr, "\tkeygen\t creates a new secrets key file and prints its public key")
+		fmt.Fprintln(os.Stderr, "\tencrypt NAME\t encrypts the value of variable NAME read from stdin")
+		fmt.Fprintln(os.Stderr, "\tdecrypt FILE\t prints FILE's variables with their values decrypted")
+		fmt.Fprintln(os.Stderr, "\trotate FILE...\t re-encrypts the encrypted values in FILEs to a new key")
+		fmt.Fprintln(os.Stderr)
+		os.Exit(1)
+	}
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		fs.Usage()
+	}
+
+	switch subcmd, args := fs.Arg(0), fs.Args()[1:]; subcmd {
+	case "keygen":
+		secretsKeygenCmd(args)
+	case "encrypt":
+		secretsEncryptCmd(args)
+	case "decrypt":
+		secretsDecryptCmd(args)
+	case "rotate":
+		secretsRotateCmd(args)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown secrets subcommand %q\n", subcmd)
+		fs.Usage()
+	}
+}
+
+func secretsKeygenCmd(args []string) {
+	fs := flag.NewFlagSet("secrets keygen", flag.ExitOnError)
+	out := fs.String("out", *secretsKey, "key file to create")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: ebc secrets keygen [OPTS]\n")
+		fmt.Fprintln(os.Stderr)
+		fmt.Fprintf(os.Stderr, "Creates a new secrets key file and prints its public key. Save the public key in %s so that values can be encrypted without the key file.\n", secretsPubFile)
+		fmt.Fprintln(os.Stderr)
+		fs.PrintDefaults()
+		fmt.Fprintln(os.Stderr)
+		os.Exit(1)
+	}
+	fs.Parse(args)
+	if fs.NArg() != 0 {
+		fmt.Fprintln(os.Stderr, "no positional args")
+		fs.Usage()
+	}
+
+	id, err := ebcvars.GenerateIdentity()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(*out), 0700); err != nil {
+		log.Fatal(err)
+	}
+	f, err := os.OpenFile(*out, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		log.Fatal("Creating key file: ", err)
+	}
+	fmt.Fprintf(f, "# ebc secrets key\n# public key: %s\n%s\n", id.Recipient(), id)
+	if err := f.Close(); err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("Wrote secrets key file %s", *out)
+	fmt.Println(id.Recipient())
+}
+
+// readRecipient returns the public key in dir's secrets.pub file, or if
+// that doesn't exist, the public key of the -secrets-key file.
+func readRecipient(dir string) (*ebcvars.Recipient, error) {
+	data, err := ioutil.ReadFile(filepath.Join(dir, secretsPubFile))
+	if err == nil {
+		return ebcvars.ParseRecipient(string(data))
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+	id, err := readSecretsKey()
+	if err != nil {
+		return nil, fmt.Errorf("no %s file, and the secrets key couldn't be read: %s", secretsPubFile, err)
+	}
+	return id.Recipient(), nil
+}
+
+func secretsEncryptCmd(args []string) {
+	fs := flag.NewFlagSet("secrets encrypt", flag.ExitOnError)
+	to := fs.String("to", "", "public key to encrypt to (default: from "+secretsPubFile+" or -secrets-key)")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: ebc secrets encrypt [OPTS] NAME\n")
+		fmt.Fprintln(os.Stderr)
+		fmt.Fprintln(os.Stderr, "Reads the value of the variable NAME from stdin (without a trailing newline), and prints a NAME=ENC[...] line to add to an env var file. The encrypted value may only be used as the value of NAME.")
+		fmt.Fprintln(os.Stderr)
+		fs.PrintDefaults()
+		fmt.Fprintln(os.Stderr)
+		os.Exit(1)
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "exactly 1 variable name must be specified")
+		fs.Usage()
+	}
+	name := fs.Arg(0)
+
+	var r *ebcvars.Recipient
+	var err error
+	if *to != "" {
+		r, err = ebcvars.ParseRecipient(*to)
+	} else {
+		r, err = readRecipient(*dir)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	value, err := ioutil.ReadAll(os.Stdin)
+	if err != nil {
+		log.Fatal(err)
+	}
+	token, err := r.Encrypt(name, strings.TrimSuffix(strings.TrimSuffix(string(value), "\n"), "\r"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("%s=%s\n", name, token)
+}
+
+func secretsDecryptCmd(args []string) {
+	fs := flag.NewFlagSet("secrets decrypt", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: ebc secrets decrypt FILE\n")
+		fmt.Fprintln(os.Stderr)
+		fmt.Fprintln(os.Stderr, "Prints the variables in FILE in dotenv format, with encrypted values decrypted with the -secrets-key file.")
+		fmt.Fprintln(os.Stderr)
+		os.Exit(1)
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "exactly 1 file must be specified")
+		fs.Usage()
+	}
+
+	l, err := ebcvars.ReadFile(fs.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+	if l == nil {
+		log.Fatalf("%s does not exist", fs.Arg(0))
+	}
+	id, err := readSecretsKey()
+	if err != nil {
+		log.Fatal(err)
+	}
+	vars, err := l.Vars.Decrypt(id)
+	if err != nil {
+		log.Fatalf("%s: %s", l.Source, err)
+	}
+	os.Stdout.Write(ebcvars.Format(vars))
+}
+
+func secretsRotateCmd(args []string) {
+	fs := flag.NewFlagSet("secrets rotate", flag.ExitOnError)
+	newKey := fs.String("new-key", "", "key file (created with `ebc secrets keygen`) to re-encrypt values to")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: ebc secrets rotate -new-key=FILE [FILE...]\n")
+		fmt.Fprintln(os.Stderr)
+		fmt.Fprintf(os.Stderr, "Decrypts each encrypted value in the given env var files with the -secrets-key file, and encrypts it again with the -new-key file's public key. The files are rewritten in place, and everything else in them is left unchanged. If no files are given, the %s/*.env and .ebc-vars.env files in the directory are rotated.\n", varsDir)
+		fmt.Fprintln(os.Stderr)
+		fmt.Fprintf(os.Stderr, "Afterwards, replace the -secrets-key file with the new key file and update %s.\n", secretsPubFile)
+		fmt.Fprintln(os.Stderr)
+		fs.PrintDefaults()
+		fmt.Fprintln(os.Stderr)
+		os.Exit(1)
+	}
+	fs.Parse(args)
+	if *newKey == "" {
+		fmt.Fprintln(os.Stderr, "-new-key is required")
+		fs.Usage()
+	}
+
+	files := fs.Args()
+	if len(files) == 0 {
+		files, _ = filepath.Glob(filepath.Join(*dir, varsDir, "*.env"))
+		if _, err := os.Stat(filepath.Join(*dir, ".ebc-vars.env")); err == nil {
+			files = append(files, filepath.Join(*dir, ".ebc-vars.env"))
+		}
+	}
+
+	oldID, err := readSecretsKey()
+	if err != nil {
+		log.Fatal(err)
+	}
+	newID, err := ebcvars.ReadIdentityFile(*newKey)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Re-encrypt all files before writing any, so that a bad file doesn't
+	// leave the others half-rotated.
+	rotated := make([][]byte, len(files))
+	counts := make([]int, len(files))
+	for i, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			log.Fatal(err)
+		}
+		rotated[i], counts[i], err = ebcvars.Reencrypt(data, oldID, newID.Recipient())
+		if err != nil {
+			log.Fatalf("%s: %s", file, err)
+		}
+	}
+	for i, file := range files {
+		if counts[i] == 0 {
+			continue
+		}
+		fi, err := os.Stat(file)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := ioutil.WriteFile(file, rotated[i], fi.Mode().Perm()); err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("Rotated %d value(s) in %s\n", counts[i], file)
+	}
+}
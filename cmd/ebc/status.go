@@ -0,0 +1,150 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/sqs/go-elasticbeanstalk/elasticbeanstalk"
+)
+
+func statusCmd(args []string) {
+	df, err := readDefaults(*dir)
+	if err != nil {
+		if *verbose {
+			log.Printf("Warning: couldn't read defaults: %s. Flag values must be explicitly specified.", err)
+		}
+		df = new(defaults)
+	}
+
+	fs := flag.NewFlagSet("status", flag.ExitOnError)
+	env := fs.String("env", df.env, "EB environment name")
+	app := fs.String("app", df.app, "EB application name")
+	numEvents := fs.Int("n", 10, "number of recent events to show")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: ebc status [OPTS]\n")
+		fmt.Fprintln(os.Stderr)
+		fmt.Fprintln(os.Stderr, "Shows an environment's status, health, deployed version, and recent events.")
+		fmt.Fprintln(os.Stderr)
+		fs.PrintDefaults()
+		fmt.Fprintln(os.Stderr)
+		os.Exit(1)
+	}
+	fs.Parse(args)
+
+	if *env == "" {
+		fmt.Fprintln(os.Stderr, "env is required")
+		fs.Usage()
+	}
+
+	if *app == "" {
+		fmt.Fprintln(os.Stderr, "app is required")
+		fs.Usage()
+	}
+
+	if fs.NArg() != 0 {
+		fmt.Fprintln(os.Stderr, "no positional args")
+		fs.Usage()
+	}
+
+	envs, err := ebClient.DescribeEnvironments(&elasticbeanstalk.DescribeEnvironmentsParams{ApplicationName: *app, EnvironmentName: *env})
+	if err != nil {
+		log.Fatal("describing environment failed: ", err)
+	}
+	e := elasticbeanstalk.CurrentEnvironment(envs)
+	if e == nil {
+		log.Fatalf("environment %q not found in application %q", *env, *app)
+	}
+
+	// Enhanced health reporting may not be enabled, in which case only the
+	// basic health color from DescribeEnvironments is shown.
+	health, err := ebClient.DescribeEnvironmentHealth(&elasticbeanstalk.DescribeEnvironmentHealthParams{
+		EnvironmentName: *env,
+		AttributeNames:  []string{"HealthStatus", "Color", "Causes"},
+	})
+	if err != nil {
+		if *verbose {
+			log.Printf("Warning: couldn't get enhanced health: %s", err)
+		}
+		health = nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(w, "Environment:\t%s (%s)\n", e.EnvironmentName, e.ApplicationName)
+	fmt.Fprintf(w, "Status:\t%s\n", e.Status)
+	if health != nil && health.HealthStatus != "" {
+		fmt.Fprintf(w, "Health:\t%s (%s)\n", colorize(health.Color, health.Color), health.HealthStatus)
+		for _, cause := range health.Causes {
+			fmt.Fprintf(w, "\t- %s\n", cause)
+		}
+	} else {
+		fmt.Fprintf(w, "Health:\t%s\n", colorize(e.Health, e.Health))
+	}
+	fmt.Fprintf(w, "Version:\t%s\n", e.VersionLabel)
+	fmt.Fprintf(w, "CNAME:\t%s\n", e.CNAME)
+	fmt.Fprintf(w, "Last updated:\t%s (%s ago)\n", e.DateUpdated.Local().Format(time.RFC1123), time.Since(e.DateUpdated.Time).Round(time.Second))
+	w.Flush()
+
+	if *numEvents <= 0 {
+		return
+	}
+	res, err := ebClient.DescribeEvents(&elasticbeanstalk.DescribeEventsParams{
+		ApplicationName: *app,
+		EnvironmentName: *env,
+		MaxRecords:      *numEvents,
+	})
+	if err != nil {
+		log.Fatal("describing events failed: ", err)
+	}
+	fmt.Println()
+	if res == nil || len(res.Events) == 0 {
+		fmt.Println("No recent events.")
+		return
+	}
+	fmt.Println("Recent events:")
+	// Show the events oldest first, like a log.
+	for i := len(res.Events) - 1; i >= 0; i-- {
+		printEvent(res.Events[i])
+	}
+}
+
+// printEvent prints an event on a single line.
+func printEvent(ev *elasticbeanstalk.EventDescription) {
+	fmt.Printf("%s  %s  %s\n", ev.EventDate.Local().Format("2006-01-02 15:04:05"), colorize(severityColors[ev.Severity], fmt.Sprintf("%-5s", ev.Severity)), ev.Message)
+}
+
+// severityColors maps event severities to the health colors used to display
+// them.
+var severityColors = map[string]string{
+	"WARN":  "Yellow",
+	"ERROR": "Red",
+	"FATAL": "Red",
+}
+
+// ansiColors maps Elastic Beanstalk health colors to ANSI escape codes.
+var ansiColors = map[string]string{
+	"Green":  "\x1b[32m",
+	"Yellow": "\x1b[33m",
+	"Red":    "\x1b[31m",
+	"Grey":   "\x1b[90m",
+}
+
+var stdoutIsTerminal = isTerminal(os.Stdout)
+
+// colorize returns s in the given health color (such as "Green") if stdout
+// is a terminal, and s unchanged otherwise.
+func colorize(color, s string) string {
+	code, ok := ansiColors[color]
+	if !ok || !stdoutIsTerminal {
+		return s
+	}
+	return code + s + "\x1b[0m"
+}
+
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
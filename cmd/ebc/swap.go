@@ -0,0 +1,225 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/sqs/go-elasticbeanstalk/elasticbeanstalk"
+)
+
+func swapCmd(args []string) {
+	df, err := readDefaults(*dir)
+	if err != nil {
+		if *verbose {
+			log.Printf("Warning: couldn't read defaults: %s. Flag values must be explicitly specified.", err)
+		}
+		df = new(defaults)
+	}
+
+	fs := flag.NewFlagSet("swap", flag.ExitOnError)
+	app := fs.String("app", df.app, "EB application name")
+	from := fs.String("from", "", "EB environment name to move the CNAME from (such as the live environment)")
+	to := fs.String("to", "", "EB environment name to move the CNAME to")
+	timeout := fs.Duration("timeout", 15*time.Minute, "how long to wait for the environments to become Ready after swapping")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: ebc swap [OPTS] -from ENV -to ENV\n")
+		fmt.Fprintln(os.Stderr)
+		fmt.Fprintln(os.Stderr, "Swaps the CNAMEs of two environments, so that traffic to -from's CNAME goes to -to (and vice versa), and waits for both to become Ready.")
+		fmt.Fprintln(os.Stderr)
+		fs.PrintDefaults()
+		fmt.Fprintln(os.Stderr)
+		os.Exit(1)
+	}
+	fs.Parse(args)
+
+	if *app == "" {
+		fmt.Fprintln(os.Stderr, "app is required")
+		fs.Usage()
+	}
+
+	if *from == "" || *to == "" {
+		fmt.Fprintln(os.Stderr, "from and to are required")
+		fs.Usage()
+	}
+
+	if *from == *to {
+		fmt.Fprintln(os.Stderr, "from and to must be different environments")
+		fs.Usage()
+	}
+
+	if fs.NArg() != 0 {
+		fmt.Fprintln(os.Stderr, "no positional args")
+		fs.Usage()
+	}
+
+	if err := swapCNAMEs(*app, *from, *to, *timeout); err != nil {
+		log.Fatal("swap failed: ", err)
+	}
+}
+
+// swapCNAMEs swaps the CNAMEs of the from and to environments and waits for
+// both to become Ready.
+func swapCNAMEs(app, from, to string, timeout time.Duration) error {
+	fmt.Printf("Swapping CNAMEs of %s and %s\n", from, to)
+	t0 := time.Now()
+	err := ebClient.SwapEnvironmentCNAMEs(&elasticbeanstalk.SwapEnvironmentCNAMEsParams{
+		SourceEnvironmentName:      from,
+		DestinationEnvironmentName: to,
+	})
+	if err != nil {
+		return fmt.Errorf("swapping CNAMEs failed: %s", err)
+	}
+	if *dryRun {
+		return nil
+	}
+	for _, env := range []string{to, from} {
+		e, err := waitForReady(app, env, t0, timeout)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s now has CNAME %s\n", env, e.CNAME)
+	}
+	return nil
+}
+
+// smokeScript is the name of the script (in the directory being deployed)
+// that checks a newly deployed environment before a blue/green deploy
+// swaps it in.
+const smokeScript = ".ebc-smoke"
+
+type blueGreenOptions struct {
+	envs      []string // the 2 environments that take turns being live
+	cname     string   // the CNAME (or its first label) of the live environment
+	scaleDown bool     // scale down the formerly live environment after swapping
+}
+
+// blueGreenDeploy deploys to whichever of bg.envs doesn't have the live
+// CNAME, waits for it to become Ready and Green, runs the smoke check script
+// (if any), and then swaps the environments' CNAMEs.
+func blueGreenDeploy(dir, app string, bucketURL *url.URL, label string, bg *blueGreenOptions, opts *deployOptions) error {
+	live, idle, err := pickBlueGreen(app, bg)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s is live at %s; deploying to %s\n", live.EnvironmentName, live.CNAME, idle.EnvironmentName)
+
+	// The idle environment may have been scaled down after it was last
+	// swapped out, so scale it like the live environment.
+	liveSettings, err := currentOptionSettings(live.EnvironmentName, app)
+	if err != nil {
+		return err
+	}
+	for _, name := range []string{"MinSize", "MaxSize"} {
+		key := elasticbeanstalk.OptionKey{Namespace: elasticbeanstalk.NamespaceAutoScalingGroup, OptionName: name}
+		if v, ok := liveSettings.Get(key); ok {
+			opts.options.Set(key, v)
+		}
+	}
+
+	opts.wait = true
+	if err := deploy(dir, idle.EnvironmentName, app, bucketURL, label, opts); err != nil {
+		return err
+	}
+
+	if !*dryRun {
+		if err := waitForGreen(app, idle.EnvironmentName, opts.timeout); err != nil {
+			return fmt.Errorf("%s (live environment %s is unchanged)", err, live.EnvironmentName)
+		}
+		if err := runSmokeScript(dir, idle); err != nil {
+			return fmt.Errorf("smoke check of %s failed: %s (live environment %s is unchanged)", idle.EnvironmentName, err, live.EnvironmentName)
+		}
+	}
+
+	if err := swapCNAMEs(app, live.EnvironmentName, idle.EnvironmentName, opts.timeout); err != nil {
+		return err
+	}
+
+	if bg.scaleDown {
+		fmt.Printf("Scaling down %s to 1 instance\n", live.EnvironmentName)
+		p := &elasticbeanstalk.UpdateEnvironmentParams{EnvironmentName: live.EnvironmentName}
+		p.AddOptions(&elasticbeanstalk.AutoScalingGroupOptions{MinSize: elasticbeanstalk.Int(1), MaxSize: elasticbeanstalk.Int(1)})
+		if err := ebClient.UpdateEnvironment(p); err != nil {
+			return fmt.Errorf("scaling down %s failed: %s", live.EnvironmentName, err)
+		}
+	}
+	return nil
+}
+
+// pickBlueGreen returns the environment of bg.envs that has the live CNAME
+// and the other (idle) one.
+func pickBlueGreen(app string, bg *blueGreenOptions) (live, idle *elasticbeanstalk.EnvironmentDescription, err error) {
+	envs, err := ebClient.DescribeEnvironments(&elasticbeanstalk.DescribeEnvironmentsParams{ApplicationName: app})
+	if err != nil {
+		return nil, nil, fmt.Errorf("describing environments failed: %s", err)
+	}
+	var pair []*elasticbeanstalk.EnvironmentDescription
+	for _, name := range bg.envs {
+		var found *elasticbeanstalk.EnvironmentDescription
+		for _, e := range envs {
+			if e.EnvironmentName == name && e.Status != "Terminated" {
+				found = e
+			}
+		}
+		if found == nil {
+			return nil, nil, fmt.Errorf("environment %q not found in application %q", name, app)
+		}
+		pair = append(pair, found)
+	}
+
+	isLive := func(e *elasticbeanstalk.EnvironmentDescription) bool {
+		cname := strings.ToLower(e.CNAME)
+		want := strings.ToLower(bg.cname)
+		return cname == want || strings.HasPrefix(cname, want+".")
+	}
+	switch a, b := isLive(pair[0]), isLive(pair[1]); {
+	case a && !b:
+		return pair[0], pair[1], nil
+	case b && !a:
+		return pair[1], pair[0], nil
+	default:
+		return nil, nil, fmt.Errorf("expected exactly one of %s (%s) and %s (%s) to have CNAME %s", pair[0].EnvironmentName, pair[0].CNAME, pair[1].EnvironmentName, pair[1].CNAME, bg.cname)
+	}
+}
+
+// waitForGreen waits for the environment's health to become Green.
+func waitForGreen(app, env string, timeout time.Duration) error {
+	w := &elasticbeanstalk.EnvironmentWaiter{
+		API:             ebClient,
+		ApplicationName: app,
+		EnvironmentName: env,
+		Timeout:         timeout,
+	}
+	e, err := w.WaitUntil(func(e *elasticbeanstalk.EnvironmentDescription) bool { return e.Health == "Green" })
+	if err == elasticbeanstalk.ErrWaitTimeout {
+		return fmt.Errorf("%s is still %s after %s", env, e.Health, timeout)
+	}
+	return err
+}
+
+// runSmokeScript runs the smoke check script in dir (if it exists) with the
+// environment's URL and name as arguments.
+func runSmokeScript(dir string, e *elasticbeanstalk.EnvironmentDescription) error {
+	scriptFile := filepath.Join(dir, smokeScript)
+	fi, err := os.Stat(scriptFile)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if !fi.Mode().IsRegular() {
+		return fmt.Errorf("%s is not a regular file", scriptFile)
+	}
+	u := "http://" + e.CNAME
+	fmt.Printf("Running smoke check %s %s\n", smokeScript, u)
+	script := exec.Command(scriptFile, u, e.EnvironmentName)
+	script.Dir = dir
+	script.Stdout, script.Stderr = os.Stdout, os.Stderr
+	return script.Run()
+}
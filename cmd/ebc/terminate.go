@@ -0,0 +1,100 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/sqs/go-elasticbeanstalk/elasticbeanstalk"
+)
+
+func terminateCmd(args []string) {
+	df, err := readDefaults(*dir)
+	if err != nil {
+		if *verbose {
+			log.Printf("Warning: couldn't read defaults: %s. Flag values must be explicitly specified.", err)
+		}
+		df = new(defaults)
+	}
+
+	fs := flag.NewFlagSet("terminate", flag.ExitOnError)
+	env := fs.String("env", "", "name of the EB environment to terminate")
+	app := fs.String("app", df.app, "EB application name")
+	yes := fs.Bool("yes", false, "terminate without asking for confirmation")
+	wait := fs.Bool("wait", false, "wait for the environment to be terminated, printing its events")
+	timeout := fs.Duration("timeout", 30*time.Minute, "how long to wait for the environment to be terminated (with -wait)")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: ebc terminate [OPTS] -env NAME\n")
+		fmt.Fprintln(os.Stderr)
+		fmt.Fprintln(os.Stderr, "Terminates an environment and the AWS resources (such as instances and load balancers) that it uses, after asking for confirmation.")
+		fmt.Fprintln(os.Stderr)
+		fs.PrintDefaults()
+		fmt.Fprintln(os.Stderr)
+		os.Exit(1)
+	}
+	fs.Parse(args)
+
+	// Unlike other commands, -env doesn't default to the directory's
+	// environment, so that an environment isn't terminated by accident.
+	if *env == "" {
+		fmt.Fprintln(os.Stderr, "env is required")
+		fs.Usage()
+	}
+
+	if *app == "" {
+		fmt.Fprintln(os.Stderr, "app is required")
+		fs.Usage()
+	}
+
+	if fs.NArg() != 0 {
+		fmt.Fprintln(os.Stderr, "no positional args")
+		fs.Usage()
+	}
+
+	envs, err := ebClient.DescribeEnvironments(&elasticbeanstalk.DescribeEnvironmentsParams{ApplicationName: *app, EnvironmentName: *env})
+	if err != nil {
+		log.Fatal("describing environment failed: ", err)
+	}
+	current := elasticbeanstalk.CurrentEnvironment(envs)
+	if current == nil {
+		log.Fatalf("environment %q not found in application %q", *env, *app)
+	}
+	if current.Status == "Terminated" {
+		log.Fatalf("environment %q is already terminated", *env)
+	}
+
+	if !*yes && !*dryRun {
+		if !isTerminal(os.Stdin) {
+			log.Fatalf("refusing to terminate %s without confirmation (use -yes to skip confirmation)", *env)
+		}
+		fmt.Printf("Environment %s (at %s) is %s", *env, current.CNAME, current.Status)
+		if current.VersionLabel != "" {
+			fmt.Printf(" and running version %q", current.VersionLabel)
+		}
+		fmt.Println(".")
+		if !askYesNo(fmt.Sprintf("Terminate environment %s and all of its resources?", *env)) {
+			log.Fatalf("termination of %s not confirmed", *env)
+		}
+	}
+
+	fmt.Printf("Terminating environment %s\n", *env)
+	since := time.Now()
+	if err := ebClient.TerminateEnvironment(&elasticbeanstalk.TerminateEnvironmentParams{EnvironmentName: *env}); err != nil {
+		log.Fatal("terminate failed: ", err)
+	}
+
+	if *dryRun {
+		fmt.Printf("Terminate planned (took %s)\n", time.Since(t0))
+		return
+	}
+	if *wait {
+		if _, err := waitForStatus(*app, *env, "Terminated", since, *timeout); err != nil {
+			log.Fatal("terminate failed: ", err)
+		}
+		fmt.Printf("Terminate completed (took %s)\n", time.Since(t0))
+	} else {
+		fmt.Printf("Terminate initiated (took %s)\n", time.Since(t0))
+	}
+}
@@ -0,0 +1,424 @@
+package main
+
+import (
+	"encoding/xml"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/sqs/go-elasticbeanstalk/elasticbeanstalk"
+)
+
+func versionsCmd(args []string) {
+	fs := flag.NewFlagSet("versions", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: ebc versions list|prune [OPTS]\n")
+		fmt.Fprintln(os.Stderr)
+		fmt.Fprintln(os.Stderr, "Lists or deletes an application's versions and their source bundles.")
+		fmt.Fprintln(os.Stderr)
+		fmt.Fprintln(os.Stderr, "The subcommands are:")
+		fmt.Fprintln(os.Stderr)
+		fmt.Fprintln(os.Stderr, "\tlist\t lists application versions, newest first")
+		fmt.Fprintln(os.Stderr, "\tprune\t deletes old application versions that aren't deployed (and orphaned source bundles, with -orphans)")
+		fmt.Fprintln(os.Stderr)
+		os.Exit(1)
+	}
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		fs.Usage()
+	}
+
+	switch subcmd, args := fs.Arg(0), fs.Args()[1:]; subcmd {
+	case "list":
+		versionsListCmd(args)
+	case "prune":
+		versionsPruneCmd(args)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown versions subcommand %q\n", subcmd)
+		fs.Usage()
+	}
+}
+
+// versionsFlags defines the -app and -bucket flags on fs, defaulting to the
+// values in the directory's EB config.
+func versionsFlags(fs *flag.FlagSet) (app, bucket *string) {
+	df, err := readDefaults(*dir)
+	if err != nil {
+		if *verbose {
+			log.Printf("Warning: couldn't read defaults: %s. Flag values must be explicitly specified.", err)
+		}
+		df = new(defaults)
+	}
+	app = fs.String("app", df.app, "EB application name")
+	bucket = fs.String("bucket", df.bucketURL, "S3 bucket URL (example: https://example-bucket.s3-us-west-2.amazonaws.com)")
+	return app, bucket
+}
+
+func versionsListCmd(args []string) {
+	fs := flag.NewFlagSet("versions list", flag.ExitOnError)
+	app, bucket := versionsFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: ebc versions list [OPTS]\n")
+		fmt.Fprintln(os.Stderr)
+		fmt.Fprintln(os.Stderr, "Lists an application's versions, newest first, with the size of each source bundle (if it's in the -bucket bucket) and the environments it's deployed to.")
+		fmt.Fprintln(os.Stderr)
+		fs.PrintDefaults()
+		fmt.Fprintln(os.Stderr)
+		os.Exit(1)
+	}
+	fs.Parse(args)
+
+	if *app == "" {
+		fmt.Fprintln(os.Stderr, "app is required")
+		fs.Usage()
+	}
+
+	if fs.NArg() != 0 {
+		fmt.Fprintln(os.Stderr, "no positional args")
+		fs.Usage()
+	}
+
+	versions, err := describeAllVersions(*app)
+	if err != nil {
+		log.Fatal(err)
+	}
+	deployed, err := deployedVersions(*app)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Sizes are only known for bundles in the bucket, and only if it can be
+	// listed.
+	var bucketName string
+	sizes := map[string]int64{}
+	if *bucket != "" {
+		bucketURL, err := url.Parse(*bucket)
+		if err != nil {
+			log.Fatal("invalid bucket URL: ", err)
+		}
+		bucketName = s3BucketFromURL(bucketURL)
+		objs, err := listS3Objects(bucketURL)
+		if err != nil {
+			log.Printf("Warning: couldn't list bucket %s, so bundle sizes are unknown: %s", bucketName, err)
+		}
+		for _, o := range objs {
+			sizes[o.Key] = o.Size
+		}
+	}
+
+	if len(versions) == 0 {
+		fmt.Printf("No versions of %s.\n", *app)
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "LABEL\tCREATED\tSIZE\tDEPLOYED TO")
+	for _, v := range versions {
+		size := "-"
+		if n, present := sizes[v.SourceBundle.S3Key]; present && v.SourceBundle.S3Bucket == bucketName {
+			size = fmt.Sprintf("%.1f MB", float64(n)/1024/1024)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", v.VersionLabel, v.DateCreated.Local().Format("2006-01-02 15:04:05"), size, strings.Join(deployed[v.VersionLabel], ", "))
+	}
+	w.Flush()
+}
+
+// orphanGracePeriod is how old a source bundle must be before prune -orphans
+// deletes it, so that bundles uploaded by a concurrent deploy (whose
+// versions haven't been created yet) aren't deleted.
+const orphanGracePeriod = time.Hour
+
+func versionsPruneCmd(args []string) {
+	fs := flag.NewFlagSet("versions prune", flag.ExitOnError)
+	app, bucket := versionsFlags(fs)
+	keep := fs.Int("keep", 10, "number of newest versions to keep")
+	olderThan := fs.String("older-than", "0", "only delete versions (and orphaned bundles) created at least this long ago (such as 72h or 30d)")
+	orphans := fs.Bool("orphans", false, "also delete source bundles in the -bucket bucket that no application version refers to")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: ebc versions prune [OPTS]\n")
+		fmt.Fprintln(os.Stderr)
+		fmt.Fprintln(os.Stderr, "Deletes an application's versions and their source bundles, except for the -keep newest versions, versions newer than -older-than, and versions deployed to any environment. Use -dry-run to see what would be deleted.")
+		fmt.Fprintln(os.Stderr)
+		fs.PrintDefaults()
+		fmt.Fprintln(os.Stderr)
+		os.Exit(1)
+	}
+	fs.Parse(args)
+
+	if *app == "" {
+		fmt.Fprintln(os.Stderr, "app is required")
+		fs.Usage()
+	}
+
+	if *keep < 0 {
+		fmt.Fprintln(os.Stderr, "keep must not be negative")
+		fs.Usage()
+	}
+
+	minAge, err := parseAge(*olderThan)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		fs.Usage()
+	}
+
+	if *orphans && *bucket == "" {
+		fmt.Fprintln(os.Stderr, "bucket is required with -orphans")
+		fs.Usage()
+	}
+
+	if fs.NArg() != 0 {
+		fmt.Fprintln(os.Stderr, "no positional args")
+		fs.Usage()
+	}
+
+	versions, err := describeAllVersions(*app)
+	if err != nil {
+		log.Fatal(err)
+	}
+	deployed, err := deployedVersions(*app)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	cutoff := time.Now().Add(-minAge)
+	prune := versionsToPrune(versions, deployed, *keep, cutoff)
+
+	if len(prune) == 0 {
+		fmt.Printf("No versions of %s to delete.\n", *app)
+	}
+	pruned := map[elasticbeanstalk.S3Location]bool{}
+	for _, v := range prune {
+		if *verbose {
+			log.Printf("Deleting version %q (created %s) and its source bundle s3://%s/%s...", v.VersionLabel, v.DateCreated.Local().Format(time.RFC1123), v.SourceBundle.S3Bucket, v.SourceBundle.S3Key)
+		}
+		err := ebClient.DeleteApplicationVersion(&elasticbeanstalk.DeleteApplicationVersionParams{
+			ApplicationName:    *app,
+			VersionLabel:       v.VersionLabel,
+			DeleteSourceBundle: true,
+		})
+		if err != nil {
+			log.Fatalf("deleting version %q failed: %s", v.VersionLabel, err)
+		}
+		pruned[v.SourceBundle] = true
+	}
+	if len(prune) > 0 && !*dryRun {
+		fmt.Printf("Deleted %d of %d versions of %s.\n", len(prune), len(versions), *app)
+	}
+
+	if *orphans {
+		bucketURL, err := url.Parse(*bucket)
+		if err != nil {
+			log.Fatal("invalid bucket URL: ", err)
+		}
+		if err := pruneOrphanedBundles(bucketURL, pruned, cutoff); err != nil {
+			log.Fatal("deleting orphaned source bundles failed: ", err)
+		}
+	}
+}
+
+// versionsToPrune returns the versions (which are newest first) to delete:
+// all but the keep newest versions, versions created after cutoff, and
+// versions deployed to any environment (keyed on version label, as returned
+// by deployedVersions).
+func versionsToPrune(versions []*elasticbeanstalk.ApplicationVersionDescription, deployed map[string][]string, keep int, cutoff time.Time) []*elasticbeanstalk.ApplicationVersionDescription {
+	var prune []*elasticbeanstalk.ApplicationVersionDescription
+	for i, v := range versions {
+		if i < keep || len(deployed[v.VersionLabel]) > 0 || v.DateCreated.After(cutoff) {
+			continue
+		}
+		prune = append(prune, v)
+	}
+	return prune
+}
+
+// pruneOrphanedBundles deletes the source bundles in the bucket that no
+// application version (of any application) refers to (see orphanedBundles).
+// Bundles in pruned, which were deleted along with their versions, are
+// skipped.
+func pruneOrphanedBundles(bucketURL *url.URL, pruned map[elasticbeanstalk.S3Location]bool, cutoff time.Time) error {
+	// The bucket may be shared with other applications, so consider the
+	// versions of all applications.
+	all, err := describeAllVersions("")
+	if err != nil {
+		return err
+	}
+	bucketName := s3BucketFromURL(bucketURL)
+	objs, err := listS3Objects(bucketURL)
+	if err != nil {
+		return fmt.Errorf("listing bucket %s: %s", bucketName, err)
+	}
+	orphans := orphanedBundles(bucketName, objs, all, pruned, cutoff, time.Now())
+	for _, o := range orphans {
+		u := s3URL(bucketURL, o.Key)
+		if *dryRun {
+			log.Printf("Dry run: not deleting orphaned source bundle %s", u)
+			continue
+		}
+		if *verbose {
+			log.Printf("Deleting orphaned source bundle %s...", u)
+		}
+		if err := deleteS3Object(u.String()); err != nil {
+			return err
+		}
+	}
+	if len(orphans) == 0 {
+		fmt.Printf("No orphaned source bundles in %s to delete.\n", bucketName)
+	} else if !*dryRun {
+		fmt.Printf("Deleted %d orphaned source bundles from %s.\n", len(orphans), bucketName)
+	}
+	return nil
+}
+
+// orphanedBundles returns the source bundles among objs (the objects in the
+// named bucket) that none of versions refers to and that aren't in pruned.
+// Bundles modified after cutoff or within orphanGracePeriod before now are
+// excluded.
+func orphanedBundles(bucketName string, objs []s3Object, versions []*elasticbeanstalk.ApplicationVersionDescription, pruned map[elasticbeanstalk.S3Location]bool, cutoff, now time.Time) []s3Object {
+	referenced := map[string]bool{}
+	for _, v := range versions {
+		if v.SourceBundle.S3Bucket == bucketName {
+			referenced[v.SourceBundle.S3Key] = true
+		}
+	}
+	if grace := now.Add(-orphanGracePeriod); grace.Before(cutoff) {
+		cutoff = grace
+	}
+	var orphans []s3Object
+	for _, o := range objs {
+		loc := elasticbeanstalk.S3Location{S3Bucket: bucketName, S3Key: o.Key}
+		if !strings.HasSuffix(o.Key, ".zip") || referenced[o.Key] || pruned[loc] || o.LastModified.After(cutoff) {
+			continue
+		}
+		orphans = append(orphans, o)
+	}
+	return orphans
+}
+
+// describeAllVersions returns all versions of the application (or of all
+// applications, if app is empty), newest first.
+func describeAllVersions(app string) ([]*elasticbeanstalk.ApplicationVersionDescription, error) {
+	var versions []*elasticbeanstalk.ApplicationVersionDescription
+	p := &elasticbeanstalk.DescribeApplicationVersionsParams{ApplicationName: app}
+	for {
+		res, err := ebClient.DescribeApplicationVersions(p)
+		if err != nil {
+			return nil, fmt.Errorf("describing application versions failed: %s", err)
+		}
+		if res == nil {
+			break
+		}
+		versions = append(versions, res.ApplicationVersions...)
+		if res.NextToken == "" {
+			break
+		}
+		p.NextToken = res.NextToken
+	}
+	sort.SliceStable(versions, func(i, j int) bool {
+		return versions[i].DateCreated.After(versions[j].DateCreated.Time)
+	})
+	return versions, nil
+}
+
+// deployedVersions returns the names of the (non-terminated) environments
+// that each version of the application is deployed to, keyed on version
+// label.
+func deployedVersions(app string) (map[string][]string, error) {
+	envs, err := ebClient.DescribeEnvironments(&elasticbeanstalk.DescribeEnvironmentsParams{ApplicationName: app})
+	if err != nil {
+		return nil, fmt.Errorf("describing environments failed: %s", err)
+	}
+	deployed := map[string][]string{}
+	for _, e := range envs {
+		if e.Status == "Terminated" || e.VersionLabel == "" {
+			continue
+		}
+		deployed[e.VersionLabel] = append(deployed[e.VersionLabel], e.EnvironmentName)
+	}
+	for _, names := range deployed {
+		sort.Strings(names)
+	}
+	return deployed, nil
+}
+
+// parseAge parses s as a duration (such as "72h"), also allowing a number of
+// days (such as "30d").
+func parseAge(s string) (time.Duration, error) {
+	if days := strings.TrimSuffix(s, "d"); days != s {
+		if n, err := strconv.Atoi(days); err == nil && n >= 0 {
+			return time.Duration(n) * 24 * time.Hour, nil
+		}
+	} else if d, err := time.ParseDuration(s); err == nil && d >= 0 {
+		return d, nil
+	}
+	return 0, fmt.Errorf("invalid age %q (must be a duration such as 72h or a number of days such as 30d)", s)
+}
+
+// An s3Object is an object in an S3 bucket listing.
+type s3Object struct {
+	Key          string
+	LastModified time.Time
+	Size         int64
+}
+
+// listS3Objects lists all objects in the bucket.
+func listS3Objects(bucketURL *url.URL) ([]s3Object, error) {
+	var objs []s3Object
+	var marker string
+	for {
+		u := *bucketURL
+		u.Path = "/"
+		u.RawQuery = url.Values{"marker": []string{marker}}.Encode()
+		var res struct {
+			IsTruncated bool
+			NextMarker  string
+			Contents    []s3Object
+		}
+		if err := s3Do("GET", u.String(), http.StatusOK, &res); err != nil {
+			return nil, err
+		}
+		objs = append(objs, res.Contents...)
+		if !res.IsTruncated || len(res.Contents) == 0 {
+			return objs, nil
+		}
+		marker = res.NextMarker
+		if marker == "" {
+			marker = res.Contents[len(res.Contents)-1].Key
+		}
+	}
+}
+
+// deleteS3Object deletes the object at url.
+func deleteS3Object(url string) error {
+	return s3Do("DELETE", url, http.StatusNoContent, nil)
+}
+
+// s3Do sends a signed S3 request and, if out is non-nil, decodes the XML
+// response body into it. It returns an error unless the response status code
+// is wantStatus.
+func s3Do(method, url string, wantStatus int, out interface{}) error {
+	r, err := http.NewRequest(method, url, nil)
+	if err != nil {
+		return err
+	}
+	r.Header.Set("Date", time.Now().UTC().Format(http.TimeFormat))
+	s3Config.Sign(r, *s3Config.Keys)
+	resp, err := s3Config.Client.Do(r)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != wantStatus {
+		return fmt.Errorf("unexpected HTTP status code for %s %s: %d %s", method, url, resp.StatusCode, http.StatusText(resp.StatusCode))
+	}
+	if out != nil {
+		return xml.NewDecoder(resp.Body).Decode(out)
+	}
+	return nil
+}
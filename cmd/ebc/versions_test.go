@@ -0,0 +1,87 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/sqs/go-elasticbeanstalk/elasticbeanstalk"
+)
+
+func TestVersionsToPrune(t *testing.T) {
+	now := time.Date(2014, 2, 28, 0, 0, 0, 0, time.UTC)
+	version := func(label string, age time.Duration) *elasticbeanstalk.ApplicationVersionDescription {
+		return &elasticbeanstalk.ApplicationVersionDescription{VersionLabel: label, DateCreated: elasticbeanstalk.Time{Time: now.Add(-age)}}
+	}
+	day := 24 * time.Hour
+	versions := []*elasticbeanstalk.ApplicationVersionDescription{ // newest first
+		version("v5", 1*day),
+		version("v4", 10*day),
+		version("v3", 20*day),
+		version("v2", 40*day),
+		version("v1", 50*day),
+	}
+	// v1 is older than any cutoff, but it's still deployed.
+	deployed := map[string][]string{"v1": {"env"}}
+
+	tests := []struct {
+		keep   int
+		minAge time.Duration
+		want   []string
+	}{
+		{keep: 0, minAge: 0, want: []string{"v5", "v4", "v3", "v2"}},
+		{keep: 2, minAge: 0, want: []string{"v3", "v2"}},
+		{keep: 0, minAge: 30 * day, want: []string{"v2"}},
+		{keep: 4, minAge: 30 * day, want: nil},
+		{keep: 10, minAge: 0, want: nil},
+	}
+	for _, test := range tests {
+		var got []string
+		for _, v := range versionsToPrune(versions, deployed, test.keep, now.Add(-test.minAge)) {
+			got = append(got, v.VersionLabel)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("keep=%d older-than=%s: got %v, want %v", test.keep, test.minAge, got, test.want)
+		}
+	}
+}
+
+func TestOrphanedBundles(t *testing.T) {
+	now := time.Date(2014, 2, 28, 0, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+	objs := []s3Object{
+		{Key: "orphan.zip", LastModified: now.Add(-2 * day)},
+		{Key: "referenced.zip", LastModified: now.Add(-2 * day)},
+		{Key: "other-app.zip", LastModified: now.Add(-2 * day)},
+		{Key: "pruned.zip", LastModified: now.Add(-2 * day)},
+		{Key: "notes.txt", LastModified: now.Add(-2 * day)},
+		{Key: "uploading.zip", LastModified: now.Add(-orphanGracePeriod / 2)},
+		{Key: "elsewhere.zip", LastModified: now.Add(-2 * day)},
+	}
+	versions := []*elasticbeanstalk.ApplicationVersionDescription{
+		{ApplicationName: "app", SourceBundle: elasticbeanstalk.S3Location{S3Bucket: "b", S3Key: "referenced.zip"}},
+		{ApplicationName: "other", SourceBundle: elasticbeanstalk.S3Location{S3Bucket: "b", S3Key: "other-app.zip"}},
+		// The same key in another bucket doesn't refer to this bucket's bundle.
+		{ApplicationName: "app", SourceBundle: elasticbeanstalk.S3Location{S3Bucket: "b2", S3Key: "elsewhere.zip"}},
+	}
+	pruned := map[elasticbeanstalk.S3Location]bool{{S3Bucket: "b", S3Key: "pruned.zip"}: true}
+
+	keys := func(objs []s3Object) []string {
+		var keys []string
+		for _, o := range objs {
+			keys = append(keys, o.Key)
+		}
+		return keys
+	}
+
+	// Bundles within the grace period are kept even without -older-than.
+	got := keys(orphanedBundles("b", objs, versions, pruned, now, now))
+	if want := []string{"orphan.zip", "elsewhere.zip"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got orphans %v, want %v", got, want)
+	}
+
+	got = keys(orphanedBundles("b", objs, versions, pruned, now.Add(-3*day), now))
+	if len(got) != 0 {
+		t.Errorf("with a cutoff before every bundle, got orphans %v, want none", got)
+	}
+}
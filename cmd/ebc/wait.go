@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/sqs/go-elasticbeanstalk/elasticbeanstalk"
+)
+
+// waitForReady waits for the environment to become Ready, printing its
+// events since the given time and each change in its status and health. It
+// returns the environment's final description, or an error if it takes
+// longer than timeout, if an ERROR or FATAL event occurs, or if the
+// environment ends up Red.
+func waitForReady(app, env string, since time.Time, timeout time.Duration) (*elasticbeanstalk.EnvironmentDescription, error) {
+	return waitForStatus(app, env, "Ready", since, timeout)
+}
+
+// waitForStatus is like waitForReady, but it waits for the environment to
+// reach the given status. The health check only applies to "Ready". If
+// status is "Terminated" and the environment no longer exists, it returns a
+// nil description.
+func waitForStatus(app, env, status string, since time.Time, timeout time.Duration) (*elasticbeanstalk.EnvironmentDescription, error) {
+	events := &elasticbeanstalk.EventPoller{
+		API: ebClient,
+		Params: elasticbeanstalk.DescribeEventsParams{
+			ApplicationName: app,
+			EnvironmentName: env,
+			StartTime:       since.UTC(),
+		},
+	}
+	var errorEvents int
+	printEvents := func() {
+		evs, err := events.Poll()
+		if err != nil {
+			// Events are only informational, so keep waiting.
+			log.Printf("Warning: describing events failed: %s", err)
+			return
+		}
+		for _, ev := range evs {
+			printEvent(ev)
+			if ev.Severity == "ERROR" || ev.Severity == "FATAL" {
+				errorEvents++
+			}
+		}
+	}
+
+	var last string
+	w := &elasticbeanstalk.EnvironmentWaiter{
+		API:             ebClient,
+		ApplicationName: app,
+		EnvironmentName: env,
+		Timeout:         timeout,
+		Progress: func(e *elasticbeanstalk.EnvironmentDescription) {
+			printEvents()
+			if s := e.Status + "/" + e.Health; s != last {
+				last = s
+				fmt.Printf("%s  %s is %s (health: %s)\n", time.Now().Format("2006-01-02 15:04:05"), env, e.Status, colorize(e.Health, e.Health))
+			}
+		},
+	}
+	t0 := time.Now()
+	e, err := w.Wait(status)
+	if err == elasticbeanstalk.ErrWaitTimeout {
+		return e, fmt.Errorf("%s is still %s after %s", env, e.Status, timeout)
+	} else if err != nil {
+		return e, err
+	}
+	// Events may be reported just after the environment reaches the status.
+	printEvents()
+	switch {
+	case errorEvents > 0:
+		return e, fmt.Errorf("%s reported %d error event(s)", env, errorEvents)
+	case status == "Ready" && e.Health == "Red":
+		return e, fmt.Errorf("%s is Ready but its health is Red", env)
+	}
+	fmt.Printf("%s is %s (took %s)\n", env, status, time.Since(t0).Round(time.Second))
+	return e, nil
+}
@@ -0,0 +1,314 @@
+// Package ebcvars reads the environment variables that ebc sets on an
+// Elastic Beanstalk environment when deploying.
+//
+// Variables are read from the output of a .ebc-vars script or from a plain
+// .ebc-vars.env file, in either dotenv or JSON format. The format is
+// detected automatically: input whose first non-space character is "{" is
+// parsed as a JSON object, and anything else as dotenv.
+//
+// The dotenv format consists of NAME=VALUE lines. Blank lines and lines
+// starting with "#" are ignored, and a leading "export " is allowed. Values
+// may be unquoted (with trailing " # comments" removed), single-quoted
+// (taken literally), or double-quoted (with \n, \r, \t, \", \\, and \$
+// escapes). Quoted values may span multiple lines.
+//
+// Variables from several sources (such as shared and per-environment files)
+// may be combined with Merge, which records the source of each final value.
+//
+// Values may be encrypted (see Recipient.Encrypt) so that var files can be
+// committed without exposing secrets; they are decrypted with Vars.Decrypt.
+package ebcvars
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// A Var is an environment variable.
+type Var struct {
+	Name  string
+	Value string
+
+	// Line is the line number where the variable was defined, or 0 if
+	// unknown.
+	Line int
+}
+
+// Vars is an ordered list of environment variables. A name may appear more
+// than once, in which case the last value wins.
+type Vars []Var
+
+// Map returns a map of each variable's name to its (last) value.
+func (vs Vars) Map() map[string]string {
+	m := make(map[string]string, len(vs))
+	for _, v := range vs {
+		m[v.Name] = v.Value
+	}
+	return m
+}
+
+// FromMap returns the variables in m, ordered by name.
+func FromMap(m map[string]string) Vars {
+	vs := make(Vars, 0, len(m))
+	for name, value := range m {
+		vs = append(vs, Var{Name: name, Value: value})
+	}
+	sort.Slice(vs, func(i, j int) bool { return vs[i].Name < vs[j].Name })
+	return vs
+}
+
+// plainValue matches values that may be written in dotenv format without
+// quotes.
+var plainValue = regexp.MustCompile(`^[^\s'"#\\$]*$`)
+
+// Format returns vs in dotenv format. Values are double-quoted (with
+// escapes) unless they would parse the same without quotes.
+func Format(vs Vars) []byte {
+	var b bytes.Buffer
+	for _, v := range vs {
+		b.WriteString(v.Name)
+		b.WriteByte('=')
+		if plainValue.MatchString(v.Value) {
+			b.WriteString(v.Value)
+		} else {
+			b.WriteByte('"')
+			for _, c := range []byte(v.Value) {
+				switch c {
+				case '\n':
+					b.WriteString(`\n`)
+				case '\r':
+					b.WriteString(`\r`)
+				case '\t':
+					b.WriteString(`\t`)
+				case '"', '\\', '$':
+					b.WriteByte('\\')
+					b.WriteByte(c)
+				default:
+					b.WriteByte(c)
+				}
+			}
+			b.WriteByte('"')
+		}
+		b.WriteByte('\n')
+	}
+	return b.Bytes()
+}
+
+// A SyntaxError describes a malformed line in dotenv or JSON input.
+type SyntaxError struct {
+	Line int
+	Msg  string
+}
+
+func (e *SyntaxError) Error() string {
+	if e.Line == 0 {
+		return e.Msg
+	}
+	return fmt.Sprintf("line %d: %s", e.Line, e.Msg)
+}
+
+// Parse parses data as a JSON object if its first non-space character is
+// "{", and as dotenv otherwise.
+func Parse(data []byte) (Vars, error) {
+	if trimmed := bytes.TrimLeft(data, " \t\r\n"); len(trimmed) > 0 && trimmed[0] == '{' {
+		return ParseJSON(data)
+	}
+	return ParseDotenv(data)
+}
+
+// ParseJSON parses data as a JSON object whose values are strings, numbers,
+// booleans, or null (which is treated as the empty string). The order of the
+// object's keys is preserved.
+func ParseJSON(data []byte) (Vars, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if tok, err := dec.Token(); err != nil {
+		return nil, &SyntaxError{Msg: err.Error()}
+	} else if tok != json.Delim('{') {
+		return nil, &SyntaxError{Msg: "expected a JSON object"}
+	}
+
+	var vs Vars
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, &SyntaxError{Msg: err.Error()}
+		}
+		name := tok.(string)
+
+		tok, err = dec.Token()
+		if err != nil {
+			return nil, &SyntaxError{Msg: err.Error()}
+		}
+		var value string
+		switch t := tok.(type) {
+		case string:
+			value = t
+		case json.Number:
+			value = t.String()
+		case bool:
+			value = fmt.Sprint(t)
+		case nil:
+		default:
+			return nil, &SyntaxError{Msg: fmt.Sprintf("value of %q must be a string, number, boolean, or null", name)}
+		}
+		vs = append(vs, Var{Name: name, Value: value})
+	}
+	if _, err := dec.Token(); err != nil {
+		return nil, &SyntaxError{Msg: err.Error()}
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, &SyntaxError{Msg: "unexpected data after JSON object"}
+	}
+	return vs, nil
+}
+
+// ParseDotenv parses data in dotenv format.
+func ParseDotenv(data []byte) (Vars, error) {
+	p := &dotenvParser{src: string(data), line: 1}
+	var vs Vars
+	for {
+		v, err := p.next()
+		if err != nil {
+			return nil, err
+		}
+		if v == nil {
+			return vs, nil
+		}
+		vs = append(vs, *v)
+	}
+}
+
+type dotenvParser struct {
+	src  string
+	pos  int
+	line int
+}
+
+func (p *dotenvParser) errorf(line int, format string, args ...interface{}) error {
+	return &SyntaxError{Line: line, Msg: fmt.Sprintf(format, args...)}
+}
+
+// readLine returns the rest of the current line (without the newline) and
+// advances to the next line.
+func (p *dotenvParser) readLine() string {
+	end := strings.IndexByte(p.src[p.pos:], '\n')
+	var s string
+	if end == -1 {
+		s = p.src[p.pos:]
+		p.pos = len(p.src)
+	} else {
+		s = p.src[p.pos : p.pos+end]
+		p.pos += end + 1
+	}
+	p.line++
+	return strings.TrimSuffix(s, "\r")
+}
+
+// next returns the next variable, or nil at the end of the input.
+func (p *dotenvParser) next() (*Var, error) {
+	for p.pos < len(p.src) {
+		line := p.line
+		rest := p.src[p.pos:]
+		if end := strings.IndexByte(rest, '\n'); end != -1 {
+			rest = rest[:end]
+		}
+		trimmed := strings.TrimSpace(rest)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			p.readLine()
+			continue
+		}
+
+		eq := strings.IndexByte(rest, '=')
+		if eq == -1 {
+			return nil, p.errorf(line, "expected NAME=VALUE")
+		}
+		name := strings.TrimSpace(rest[:eq])
+		if strings.HasPrefix(name, "export ") || strings.HasPrefix(name, "export\t") {
+			name = strings.TrimSpace(name[len("export"):])
+		}
+		if name == "" {
+			return nil, p.errorf(line, "missing variable name before =")
+		}
+		if strings.ContainsAny(name, " \t") {
+			return nil, p.errorf(line, "invalid variable name %q", name)
+		}
+		p.pos += eq + 1
+
+		value, err := p.value(line)
+		if err != nil {
+			return nil, err
+		}
+		return &Var{Name: name, Value: value, Line: line}, nil
+	}
+	return nil, nil
+}
+
+// value parses the value that starts at the current position (just after
+// the "="), and advances past the end of the line it ends on.
+func (p *dotenvParser) value(line int) (string, error) {
+	for p.pos < len(p.src) && (p.src[p.pos] == ' ' || p.src[p.pos] == '\t') {
+		p.pos++
+	}
+	if p.pos == len(p.src) {
+		return "", nil
+	}
+
+	switch quote := p.src[p.pos]; quote {
+	case '\'', '"':
+		p.pos++
+		var b strings.Builder
+		for {
+			if p.pos >= len(p.src) {
+				return "", p.errorf(line, "unterminated %c-quoted value", quote)
+			}
+			c := p.src[p.pos]
+			p.pos++
+			switch {
+			case c == quote:
+				// Only whitespace and a comment may follow the closing quote.
+				if rest := strings.TrimSpace(p.readLine()); rest != "" && !strings.HasPrefix(rest, "#") {
+					return "", p.errorf(p.line-1, "unexpected %q after quoted value", rest)
+				}
+				return b.String(), nil
+			case c == '\\' && quote == '"' && p.pos < len(p.src):
+				e := p.src[p.pos]
+				p.pos++
+				switch e {
+				case 'n':
+					b.WriteByte('\n')
+				case 'r':
+					b.WriteByte('\r')
+				case 't':
+					b.WriteByte('\t')
+				case '"', '\\', '$':
+					b.WriteByte(e)
+				default:
+					if e == '\n' {
+						p.line++
+					}
+					b.WriteByte('\\')
+					b.WriteByte(e)
+				}
+			default:
+				if c == '\n' {
+					p.line++
+				}
+				b.WriteByte(c)
+			}
+		}
+	default:
+		v := p.readLine()
+		if i := strings.Index(v, " #"); i != -1 {
+			v = v[:i]
+		} else if i := strings.Index(v, "\t#"); i != -1 {
+			v = v[:i]
+		}
+		return strings.TrimSpace(v), nil
+	}
+}
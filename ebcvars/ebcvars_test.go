@@ -0,0 +1,120 @@
+package ebcvars
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse_dotenv(t *testing.T) {
+	data := `# comment
+FOO=bar
+export EXPORTED=1
+  SPACED = value with spaces   # trailing comment
+EMPTY=
+HASH=a#b
+SINGLE='literal \n $x # not a comment'
+DOUBLE="tab\there \"quoted\" \\ \$HOME"
+PEM="-----BEGIN KEY-----
+abc
+-----END KEY-----"
+ESCAPED_NL="line1\nline2" # comment
+LAST=1`
+	want := Vars{
+		{Name: "FOO", Value: "bar", Line: 2},
+		{Name: "EXPORTED", Value: "1", Line: 3},
+		{Name: "SPACED", Value: "value with spaces", Line: 4},
+		{Name: "EMPTY", Value: "", Line: 5},
+		{Name: "HASH", Value: "a#b", Line: 6},
+		{Name: "SINGLE", Value: `literal \n $x # not a comment`, Line: 7},
+		{Name: "DOUBLE", Value: "tab\there \"quoted\" \\ $HOME", Line: 8},
+		{Name: "PEM", Value: "-----BEGIN KEY-----\nabc\n-----END KEY-----", Line: 9},
+		{Name: "ESCAPED_NL", Value: "line1\nline2", Line: 12},
+		{Name: "LAST", Value: "1", Line: 13},
+	}
+	got, err := Parse([]byte(data))
+	if err != nil {
+		t.Fatalf("Parse returned error: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v\nwant %+v", got, want)
+	}
+}
+
+func TestParse_dotenvErrors(t *testing.T) {
+	tests := map[string]string{
+		"A=1\nnot a var\n":        "line 2: expected NAME=VALUE",
+		"=x":                      "line 1: missing variable name before =",
+		"A B=x":                   `line 1: invalid variable name "A B"`,
+		"A=1\nB=\"unterminated\n": `line 2: unterminated "-quoted value`,
+		"A='x' y":                 `line 1: unexpected "y" after quoted value`,
+	}
+	for data, want := range tests {
+		_, err := Parse([]byte(data))
+		if err == nil || err.Error() != want {
+			t.Errorf("Parse(%q): got error %v, want %q", data, err, want)
+		}
+	}
+}
+
+func TestParse_json(t *testing.T) {
+	data := ` {"B": "multi\nline", "A": 1.5, "C": true, "D": null}`
+	want := Vars{
+		{Name: "B", Value: "multi\nline"},
+		{Name: "A", Value: "1.5"},
+		{Name: "C", Value: "true"},
+		{Name: "D", Value: ""},
+	}
+	got, err := Parse([]byte(data))
+	if err != nil {
+		t.Fatalf("Parse returned error: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	if _, err := Parse([]byte(`{"A": {"nested": 1}}`)); err == nil {
+		t.Error("Parse of nested object returned no error")
+	}
+}
+
+func TestVars_Map(t *testing.T) {
+	got := Vars{{Name: "A", Value: "1"}, {Name: "A", Value: "2"}}.Map()
+	if want := map[string]string{"A": "2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestFromMap(t *testing.T) {
+	got := FromMap(map[string]string{"B": "2", "A": "1", "C": ""})
+	want := Vars{{Name: "A", Value: "1"}, {Name: "B", Value: "2"}, {Name: "C", Value: ""}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+	if m := got.Map(); len(m) != 3 || m["B"] != "2" {
+		t.Errorf("Map of FromMap result = %v", m)
+	}
+}
+
+func TestFormat(t *testing.T) {
+	vars := Vars{
+		{Name: "PLAIN", Value: "a=b/c:d"},
+		{Name: "EMPTY", Value: ""},
+		{Name: "SPACES", Value: " padded value "},
+		{Name: "SPECIAL", Value: "it's \"$HOME\" \\ #1\n\ttab\r"},
+	}
+	data := Format(vars)
+	if want := "PLAIN=a=b/c:d\nEMPTY=\nSPACES=\" padded value \"\n"; !strings.HasPrefix(string(data), want) {
+		t.Errorf("got %q, want prefix %q", data, want)
+	}
+	got, err := ParseDotenv(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range got {
+		got[i].Line = 0
+	}
+	if !reflect.DeepEqual(got, vars) {
+		t.Errorf("round trip: got %+v, want %+v", got, vars)
+	}
+}
@@ -0,0 +1,81 @@
+package ebcvars
+
+import (
+	"fmt"
+	"os"
+)
+
+// A Layer is a set of variables read from a single source, such as a file
+// or the output of a script.
+type Layer struct {
+	// Source describes where the variables came from (such as a filename).
+	Source string
+
+	Vars Vars
+}
+
+// ReadFile reads and parses the named file (see Parse). If the file does
+// not exist, it returns a nil Layer and no error.
+func ReadFile(filename string) (*Layer, error) {
+	data, err := os.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	vars, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", filename, err)
+	}
+	return &Layer{Source: filename, Vars: vars}, nil
+}
+
+// A ResolvedVar is a variable's final value after merging layers.
+type ResolvedVar struct {
+	Var
+
+	// Source is the source of the layer that set the final value.
+	Source string
+
+	// Overridden lists the sources of earlier layers that also set the
+	// variable, in order.
+	Overridden []string
+}
+
+// Merge merges layers in order, so that a variable set in a later layer
+// overrides the same variable set in an earlier layer. The result contains
+// each variable once, in the order it first appears. A variable that is set
+// more than once in the same layer is an error, since it's most likely a
+// mistake.
+func Merge(layers ...*Layer) ([]ResolvedVar, error) {
+	var merged []ResolvedVar
+	pos := map[string]int{}
+	for _, l := range layers {
+		if l == nil {
+			continue
+		}
+		inLayer := make(map[string]Var, len(l.Vars))
+		for _, v := range l.Vars {
+			if prev, dup := inLayer[v.Name]; dup {
+				if prev.Line != 0 && v.Line != 0 {
+					return nil, fmt.Errorf("%s: variable %s is set more than once (lines %d and %d)", l.Source, v.Name, prev.Line, v.Line)
+				}
+				return nil, fmt.Errorf("%s: variable %s is set more than once", l.Source, v.Name)
+			}
+			inLayer[v.Name] = v
+
+			i, present := pos[v.Name]
+			if !present {
+				pos[v.Name] = len(merged)
+				merged = append(merged, ResolvedVar{Var: v, Source: l.Source})
+				continue
+			}
+			r := &merged[i]
+			if r.Source != l.Source {
+				r.Overridden = append(r.Overridden, r.Source)
+			}
+			r.Var, r.Source = v, l.Source
+		}
+	}
+	return merged, nil
+}
@@ -0,0 +1,71 @@
+package ebcvars
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestMerge(t *testing.T) {
+	global := &Layer{Source: "global", Vars: Vars{{Name: "A", Value: "1"}, {Name: "B", Value: "1"}}}
+	app := &Layer{Source: "app", Vars: Vars{{Name: "B", Value: "2"}, {Name: "C", Value: "2"}}}
+	env := &Layer{Source: "env", Vars: Vars{{Name: "B", Value: "3"}, {Name: "C", Value: "3"}}}
+
+	got, err := Merge(global, nil, app, env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []ResolvedVar{
+		{Var: Var{Name: "A", Value: "1"}, Source: "global"},
+		{Var: Var{Name: "B", Value: "3"}, Source: "env", Overridden: []string{"global", "app"}},
+		{Var: Var{Name: "C", Value: "3"}, Source: "env", Overridden: []string{"app"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v\nwant %+v", got, want)
+	}
+}
+
+func TestMerge_duplicateInLayer(t *testing.T) {
+	global := &Layer{Source: "global", Vars: Vars{{Name: "A", Value: "1", Line: 1}}}
+	env := &Layer{Source: "env", Vars: Vars{{Name: "A", Value: "2", Line: 1}, {Name: "B", Value: "2", Line: 2}, {Name: "A", Value: "3", Line: 3}}}
+	_, err := Merge(global, env)
+	if want := "env: variable A is set more than once (lines 1 and 3)"; err == nil || err.Error() != want {
+		t.Errorf("got error %v, want %q", err, want)
+	}
+
+	script := &Layer{Source: "script", Vars: Vars{{Name: "A", Value: "1"}, {Name: "A", Value: "2"}}}
+	_, err = Merge(script)
+	if want := "script: variable A is set more than once"; err == nil || err.Error() != want {
+		t.Errorf("got error %v, want %q", err, want)
+	}
+}
+
+func TestReadFile(t *testing.T) {
+	dir := t.TempDir()
+
+	l, err := ReadFile(filepath.Join(dir, "missing.env"))
+	if err != nil || l != nil {
+		t.Fatalf("missing file: got %v, %v, want nil, nil", l, err)
+	}
+
+	filename := filepath.Join(dir, "a.env")
+	if err := os.WriteFile(filename, []byte("A=1\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	l, err = ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &Layer{Source: filename, Vars: Vars{{Name: "A", Value: "1", Line: 1}}}
+	if !reflect.DeepEqual(l, want) {
+		t.Errorf("got %+v, want %+v", l, want)
+	}
+
+	if err := os.WriteFile(filename, []byte("bad\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadFile(filename); err == nil || err.Error() != filename+": line 1: expected NAME=VALUE" {
+		t.Errorf("got error %v", err)
+	}
+}
@@ -0,0 +1,229 @@
+package ebcvars
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// A Schema declares rules that an environment's variables must satisfy. It
+// is written in YAML, for example:
+//
+//	vars:
+//	  DATABASE_URL:
+//	    required: true
+//	    pattern: 'postgres://.*'
+//	  LOG_LEVEL:
+//	    allowed: [debug, info, warn, error]
+//	environments:
+//	  - match: 'prod-*'
+//	    vars:
+//	      LOG_LEVEL:
+//	        allowed: [info, warn, error]
+//	      SENTRY_DSN:
+//	        required: true
+//
+// The rules under vars apply to every environment. Each entry under
+// environments applies to the environments whose names match its glob
+// pattern (see path.Match), and overrides the fields it sets in earlier
+// rules for the same variable. A required variable must be set to a
+// non-empty value. A pattern must match the whole value.
+type Schema struct {
+	vars map[string]*rule
+	envs []envRules
+}
+
+type envRules struct {
+	match string
+	vars  map[string]*rule
+}
+
+// rule is a rule as written in the schema. Nil fields are unset, so that
+// an environment's rule can override some fields of the base rule but not
+// others.
+type rule struct {
+	Required *bool    `yaml:"required"`
+	Pattern  *string  `yaml:"pattern"`
+	Allowed  []string `yaml:"allowed"`
+}
+
+// A Rule is the rule that a variable must satisfy in an environment.
+type Rule struct {
+	Required bool
+	Pattern  *regexp.Regexp // nil if any value is allowed
+	Allowed  []string       // nil if any value is allowed
+}
+
+type schemaYAML struct {
+	Vars         map[string]*rule `yaml:"vars"`
+	Environments []struct {
+		Match string           `yaml:"match"`
+		Vars  map[string]*rule `yaml:"vars"`
+	} `yaml:"environments"`
+}
+
+// ParseSchema parses a schema (see Schema).
+func ParseSchema(data []byte) (*Schema, error) {
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	var sy schemaYAML
+	if err := dec.Decode(&sy); err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	s := &Schema{vars: sy.Vars}
+	if err := checkRules(sy.Vars); err != nil {
+		return nil, err
+	}
+	for i, e := range sy.Environments {
+		if e.Match == "" {
+			return nil, fmt.Errorf("environments[%d]: match is required", i)
+		}
+		if _, err := path.Match(e.Match, ""); err != nil {
+			return nil, fmt.Errorf("environments[%d]: invalid match pattern %q: %s", i, e.Match, err)
+		}
+		if err := checkRules(e.Vars); err != nil {
+			return nil, fmt.Errorf("environments[%d] (%s): %s", i, e.Match, err)
+		}
+		s.envs = append(s.envs, envRules{match: e.Match, vars: e.Vars})
+	}
+	return s, nil
+}
+
+func checkRules(rules map[string]*rule) error {
+	for name, r := range rules {
+		if r == nil {
+			return fmt.Errorf("%s: empty rule", name)
+		}
+		if r.Pattern != nil {
+			if _, err := compilePattern(*r.Pattern); err != nil {
+				return fmt.Errorf("%s: invalid pattern: %s", name, err)
+			}
+		}
+	}
+	return nil
+}
+
+func compilePattern(p string) (*regexp.Regexp, error) {
+	return regexp.Compile(`^(?:` + p + `)$`)
+}
+
+// ReadSchemaFile reads and parses the named schema file. If the file does
+// not exist, it returns a nil Schema and no error.
+func ReadSchemaFile(filename string) (*Schema, error) {
+	data, err := os.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	s, err := ParseSchema(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", filename, err)
+	}
+	return s, nil
+}
+
+// Rules returns the rules that apply to each variable in the named
+// environment.
+func (s *Schema) Rules(env string) map[string]*Rule {
+	merged := map[string]*rule{}
+	apply := func(rules map[string]*rule) {
+		for name, r := range rules {
+			m := merged[name]
+			if m == nil {
+				m = &rule{}
+				merged[name] = m
+			}
+			if r.Required != nil {
+				m.Required = r.Required
+			}
+			if r.Pattern != nil {
+				m.Pattern = r.Pattern
+			}
+			if r.Allowed != nil {
+				m.Allowed = r.Allowed
+			}
+		}
+	}
+	apply(s.vars)
+	for _, e := range s.envs {
+		if ok, _ := path.Match(e.match, env); ok {
+			apply(e.vars)
+		}
+	}
+
+	rules := make(map[string]*Rule, len(merged))
+	for name, m := range merged {
+		r := &Rule{Allowed: m.Allowed}
+		if m.Required != nil {
+			r.Required = *m.Required
+		}
+		if m.Pattern != nil && *m.Pattern != "" {
+			r.Pattern, _ = compilePattern(*m.Pattern) // checked by ParseSchema
+		}
+		rules[name] = r
+	}
+	return rules
+}
+
+// A SchemaError describes every variable that doesn't satisfy a schema.
+// Violations never include variables' values.
+type SchemaError struct {
+	Env        string
+	Violations []string
+}
+
+func (e *SchemaError) Error() string {
+	return fmt.Sprintf("env vars for %s don't satisfy the schema (%d problems):\n - %s", e.Env, len(e.Violations), strings.Join(e.Violations, "\n - "))
+}
+
+// Check checks the variables that the named environment will have against
+// the schema. It returns a *SchemaError listing every violation, or nil if
+// there are none.
+func (s *Schema) Check(env string, vars map[string]string) error {
+	rules := s.Rules(env)
+	names := make([]string, 0, len(rules))
+	for name := range rules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var violations []string
+	for _, name := range names {
+		r := rules[name]
+		value, set := vars[name]
+		if !set || value == "" {
+			if r.Required {
+				violations = append(violations, fmt.Sprintf("%s is required but is not set", name))
+			}
+			continue
+		}
+		if r.Pattern != nil && !r.Pattern.MatchString(value) {
+			violations = append(violations, fmt.Sprintf("%s does not match the pattern %s", name, strings.TrimSuffix(strings.TrimPrefix(r.Pattern.String(), "^(?:"), ")$")))
+		}
+		if r.Allowed != nil && !contains(r.Allowed, value) {
+			violations = append(violations, fmt.Sprintf("%s is not one of the allowed values (%s)", name, strings.Join(r.Allowed, ", ")))
+		}
+	}
+	if len(violations) > 0 {
+		return &SchemaError{Env: env, Violations: violations}
+	}
+	return nil
+}
+
+func contains(list []string, s string) bool {
+	for _, x := range list {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,115 @@
+package ebcvars
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const testSchema = `
+vars:
+  DATABASE_URL:
+    required: true
+    pattern: 'postgres://.*'
+  LOG_LEVEL:
+    allowed: [debug, info, warn, error]
+  OPTIONAL:
+    pattern: '[0-9]+'
+environments:
+  - match: 'prod-*'
+    vars:
+      LOG_LEVEL:
+        allowed: [info, warn, error]
+      SENTRY_DSN:
+        required: true
+  - match: prod-canary
+    vars:
+      SENTRY_DSN:
+        required: false
+`
+
+func TestSchema_Check(t *testing.T) {
+	s, err := ParseSchema([]byte(testSchema))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		env  string
+		vars map[string]string
+		want []string
+	}{
+		{
+			env:  "staging",
+			vars: map[string]string{"DATABASE_URL": "postgres://db", "LOG_LEVEL": "debug"},
+		},
+		{
+			env:  "staging",
+			vars: map[string]string{"DATABASE_URL": "", "LOG_LEVEL": "verbose", "OPTIONAL": "x1"},
+			want: []string{
+				"DATABASE_URL is required but is not set",
+				"LOG_LEVEL is not one of the allowed values (debug, info, warn, error)",
+				"OPTIONAL does not match the pattern [0-9]+",
+			},
+		},
+		{
+			env:  "prod-web",
+			vars: map[string]string{"DATABASE_URL": "mysql://db", "LOG_LEVEL": "debug"},
+			want: []string{
+				"DATABASE_URL does not match the pattern postgres://.*",
+				"LOG_LEVEL is not one of the allowed values (info, warn, error)",
+				"SENTRY_DSN is required but is not set",
+			},
+		},
+		{
+			env:  "prod-canary",
+			vars: map[string]string{"DATABASE_URL": "postgres://db"},
+		},
+	}
+	for _, test := range tests {
+		err := s.Check(test.env, test.vars)
+		var got []string
+		if err != nil {
+			got = err.(*SchemaError).Violations
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s %v: got violations %q, want %q", test.env, test.vars, got, test.want)
+		}
+	}
+}
+
+func TestParseSchema_errors(t *testing.T) {
+	tests := map[string]string{
+		"vars:\n  A:\n    requird: true\n": "yaml: unmarshal errors:\n  line 3: field requird not found in type ebcvars.rule",
+		"vars:\n  A:\n    pattern: '('\n":  "A: invalid pattern: error parsing regexp: missing closing ): `^(?:()$`",
+		"environments:\n  - vars: {}\n":    "environments[0]: match is required",
+		"environments:\n  - match: '['\n":  `environments[0]: invalid match pattern "[": syntax error in pattern`,
+		"vars:\n  A:\n":                    "A: empty rule",
+	}
+	for data, want := range tests {
+		_, err := ParseSchema([]byte(data))
+		if err == nil || err.Error() != want {
+			t.Errorf("ParseSchema(%q): got error %v, want %q", data, err, want)
+		}
+	}
+}
+
+func TestReadSchemaFile(t *testing.T) {
+	dir := t.TempDir()
+	if s, err := ReadSchemaFile(filepath.Join(dir, "missing")); s != nil || err != nil {
+		t.Errorf("missing file: got %v, %v, want nil, nil", s, err)
+	}
+
+	filename := filepath.Join(dir, ".ebc-vars.schema")
+	if err := os.WriteFile(filename, []byte(testSchema), 0644); err != nil {
+		t.Fatal(err)
+	}
+	s, err := ReadSchemaFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r := s.Rules("prod-web")["SENTRY_DSN"]; r == nil || !r.Required {
+		t.Errorf("got SENTRY_DSN rule %+v, want required", r)
+	}
+}
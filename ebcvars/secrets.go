@@ -0,0 +1,298 @@
+package ebcvars
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// Secret values are encrypted to a recipient's X25519 public key, so that
+// anyone may encrypt a value but only holders of the matching key file can
+// decrypt it. Each value is encrypted with AES-256-GCM under a key derived
+// (with HKDF-SHA256) from an X25519 exchange between a fresh ephemeral key
+// and the recipient's key. The variable's name is authenticated along with
+// the value, so an encrypted value can't be moved to another variable.
+//
+// An encrypted value is written as
+//
+//	ENC[v1:BASE64]
+//
+// where BASE64 encodes the 32-byte ephemeral public key followed by the
+// ciphertext.
+
+const (
+	identityPrefix  = "EBC-SECRET-KEY-"
+	recipientPrefix = "ebc-pub-"
+	tokenPrefix     = "ENC[v1:"
+	tokenSuffix     = "]"
+	hkdfInfo        = "ebc-secrets v1"
+)
+
+// tokenPattern matches an encrypted value.
+var tokenPattern = regexp.MustCompile(`ENC\[v1:[A-Za-z0-9+/=]+\]`)
+
+// IsEncrypted reports whether value is an encrypted value.
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, tokenPrefix) && strings.HasSuffix(value, tokenSuffix)
+}
+
+// An Identity is a private key that decrypts values encrypted to its
+// Recipient.
+type Identity struct {
+	key *ecdh.PrivateKey
+}
+
+// GenerateIdentity returns a new random identity.
+func GenerateIdentity() (*Identity, error) {
+	key, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return &Identity{key: key}, nil
+}
+
+// ParseIdentity parses an identity in the format returned by
+// Identity.String.
+func ParseIdentity(s string) (*Identity, error) {
+	b, err := decodeKey(s, identityPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("invalid secret key: %s", err)
+	}
+	key, err := ecdh.X25519().NewPrivateKey(b)
+	if err != nil {
+		return nil, fmt.Errorf("invalid secret key: %s", err)
+	}
+	return &Identity{key: key}, nil
+}
+
+// ReadIdentityFile reads an identity from a key file, which contains the
+// identity on a line by itself. Blank lines and lines starting with "#" are
+// ignored.
+func ReadIdentityFile(filename string) (*Identity, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	s := bufio.NewScanner(bytes.NewReader(data))
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		id, err := ParseIdentity(line)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", filename, err)
+		}
+		return id, nil
+	}
+	return nil, fmt.Errorf("%s: no secret key found", filename)
+}
+
+// String returns the identity's private key in text form. It must be kept
+// secret.
+func (id *Identity) String() string {
+	return identityPrefix + base64.RawURLEncoding.EncodeToString(id.key.Bytes())
+}
+
+// Recipient returns the public key that values are encrypted to for id.
+func (id *Identity) Recipient() *Recipient {
+	return &Recipient{key: id.key.PublicKey()}
+}
+
+// A Recipient is a public key that values may be encrypted to.
+type Recipient struct {
+	key *ecdh.PublicKey
+}
+
+// ParseRecipient parses a recipient in the format returned by
+// Recipient.String.
+func ParseRecipient(s string) (*Recipient, error) {
+	b, err := decodeKey(s, recipientPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key: %s", err)
+	}
+	key, err := ecdh.X25519().NewPublicKey(b)
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key: %s", err)
+	}
+	return &Recipient{key: key}, nil
+}
+
+func (r *Recipient) String() string {
+	return recipientPrefix + base64.RawURLEncoding.EncodeToString(r.key.Bytes())
+}
+
+func decodeKey(s, prefix string) ([]byte, error) {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, prefix) {
+		return nil, fmt.Errorf("expected %s prefix", prefix)
+	}
+	return base64.RawURLEncoding.DecodeString(strings.TrimPrefix(s, prefix))
+}
+
+// aead returns the cipher for values exchanged between the ephemeral and
+// recipient public keys, given their shared secret.
+func aead(shared []byte, ephemeral, recipient *ecdh.PublicKey) (cipher.AEAD, error) {
+	salt := append(append([]byte(nil), ephemeral.Bytes()...), recipient.Bytes()...)
+	block, err := aes.NewCipher(hkdfSHA256(shared, salt, []byte(hkdfInfo)))
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// hkdfSHA256 derives a 32-byte key from secret using HKDF-SHA256 (RFC 5869).
+// A single block of output is all that is needed for an AES-256 key.
+func hkdfSHA256(secret, salt, info []byte) []byte {
+	extract := hmac.New(sha256.New, salt)
+	extract.Write(secret)
+	expand := hmac.New(sha256.New, extract.Sum(nil))
+	expand.Write(info)
+	expand.Write([]byte{1})
+	return expand.Sum(nil)
+}
+
+// Encrypt encrypts the value of the variable named name to r, and returns
+// the encrypted value.
+func (r *Recipient) Encrypt(name, value string) (string, error) {
+	eph, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return "", err
+	}
+	shared, err := eph.ECDH(r.key)
+	if err != nil {
+		return "", err
+	}
+	gcm, err := aead(shared, eph.PublicKey(), r.key)
+	if err != nil {
+		return "", err
+	}
+	// Each key is used only once, so a zero nonce is safe.
+	nonce := make([]byte, gcm.NonceSize())
+	out := gcm.Seal(eph.PublicKey().Bytes(), nonce, []byte(value), []byte(name))
+	return tokenPrefix + base64.StdEncoding.EncodeToString(out) + tokenSuffix, nil
+}
+
+// ErrDecrypt is returned when an encrypted value can't be decrypted, either
+// because it was encrypted to a different key or because it was modified.
+var ErrDecrypt = errors.New("decryption failed (wrong key, or value was modified or moved from another variable)")
+
+// Decrypt decrypts the encrypted value of the variable named name.
+func (id *Identity) Decrypt(name, value string) (string, error) {
+	if !IsEncrypted(value) {
+		return "", errors.New("value is not encrypted")
+	}
+	data, err := base64.StdEncoding.DecodeString(strings.TrimSuffix(strings.TrimPrefix(value, tokenPrefix), tokenSuffix))
+	if err != nil {
+		return "", fmt.Errorf("invalid encrypted value: %s", err)
+	}
+	const keySize = 32
+	if len(data) < keySize {
+		return "", errors.New("invalid encrypted value: too short")
+	}
+	eph, err := ecdh.X25519().NewPublicKey(data[:keySize])
+	if err != nil {
+		return "", fmt.Errorf("invalid encrypted value: %s", err)
+	}
+	shared, err := id.key.ECDH(eph)
+	if err != nil {
+		return "", ErrDecrypt
+	}
+	gcm, err := aead(shared, eph, id.key.PublicKey())
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := gcm.Open(nil, make([]byte, gcm.NonceSize()), data[keySize:], []byte(name))
+	if err != nil {
+		return "", ErrDecrypt
+	}
+	return string(plaintext), nil
+}
+
+// Encrypted reports whether any of the variables' values are encrypted.
+func (vs Vars) Encrypted() bool {
+	for _, v := range vs {
+		if IsEncrypted(v.Value) {
+			return true
+		}
+	}
+	return false
+}
+
+// Decrypt returns a copy of vs in which encrypted values are decrypted with
+// id. Errors identify variables by name and line, never by value.
+func (vs Vars) Decrypt(id *Identity) (Vars, error) {
+	out := make(Vars, len(vs))
+	for i, v := range vs {
+		out[i] = v
+		if !IsEncrypted(v.Value) {
+			continue
+		}
+		if id == nil {
+			return nil, fmt.Errorf("%s is encrypted, but no secret key was given", v.Name)
+		}
+		value, err := id.Decrypt(v.Name, v.Value)
+		if err != nil {
+			return nil, varError(v, err)
+		}
+		out[i].Value = value
+	}
+	return out, nil
+}
+
+func varError(v Var, err error) error {
+	if v.Line != 0 {
+		return fmt.Errorf("line %d: %s: %s", v.Line, v.Name, err)
+	}
+	return fmt.Errorf("%s: %s", v.Name, err)
+}
+
+// Reencrypt rewrites each encrypted value in data (in dotenv or JSON
+// format), decrypting it with id and encrypting it again to r. The rest of
+// data is left unchanged. It returns the number of values rewritten.
+func Reencrypt(data []byte, id *Identity, r *Recipient) ([]byte, int, error) {
+	vars, err := Parse(data)
+	if err != nil {
+		return nil, 0, err
+	}
+	names := map[string]string{}
+	for _, v := range vars {
+		if IsEncrypted(v.Value) {
+			names[v.Value] = v.Name
+		}
+	}
+
+	var n int
+	var firstErr error
+	out := tokenPattern.ReplaceAllStringFunc(string(data), func(token string) string {
+		name, ok := names[token]
+		if !ok || firstErr != nil {
+			return token
+		}
+		value, err := id.Decrypt(name, token)
+		if err == nil {
+			token, err = r.Encrypt(name, value)
+		}
+		if err != nil {
+			firstErr = fmt.Errorf("%s: %s", name, err)
+			return token
+		}
+		n++
+		return token
+	})
+	if firstErr != nil {
+		return nil, 0, firstErr
+	}
+	return []byte(out), n, nil
+}
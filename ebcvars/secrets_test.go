@@ -0,0 +1,148 @@
+package ebcvars
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newIdentity(t *testing.T) *Identity {
+	id, err := GenerateIdentity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	id := newIdentity(t)
+	token, err := id.Recipient().Encrypt("DB_PASSWORD", "hunter2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsEncrypted(token) || strings.Contains(token, "hunter2") {
+		t.Fatalf("bad token %q", token)
+	}
+
+	got, err := id.Decrypt("DB_PASSWORD", token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "hunter2" {
+		t.Errorf("got %q, want %q", got, "hunter2")
+	}
+
+	if _, err := id.Decrypt("OTHER_VAR", token); err != ErrDecrypt {
+		t.Errorf("decrypt with wrong name: got error %v, want ErrDecrypt", err)
+	}
+	if _, err := newIdentity(t).Decrypt("DB_PASSWORD", token); err != ErrDecrypt {
+		t.Errorf("decrypt with wrong key: got error %v, want ErrDecrypt", err)
+	}
+}
+
+func TestParseIdentityAndRecipient(t *testing.T) {
+	id := newIdentity(t)
+	id2, err := ParseIdentity(id.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id2.String() != id.String() {
+		t.Errorf("identity round trip: got %s, want %s", id2, id)
+	}
+	r, err := ParseRecipient(id.Recipient().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.String() != id.Recipient().String() {
+		t.Errorf("recipient round trip: got %s, want %s", r, id.Recipient())
+	}
+
+	if _, err := ParseIdentity(r.String()); err == nil {
+		t.Error("ParseIdentity accepted a public key")
+	}
+}
+
+func TestReadIdentityFile(t *testing.T) {
+	id := newIdentity(t)
+	filename := filepath.Join(t.TempDir(), "secrets.key")
+	data := "# created by test\n# public key: " + id.Recipient().String() + "\n\n" + id.String() + "\n"
+	if err := os.WriteFile(filename, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadIdentityFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.String() != id.String() {
+		t.Errorf("got %s, want %s", got, id)
+	}
+}
+
+func TestVars_Decrypt(t *testing.T) {
+	id := newIdentity(t)
+	token, err := id.Recipient().Encrypt("SECRET", "s3cret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	vars, err := Parse([]byte("PLAIN=1\nSECRET=" + token + "\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !vars.Encrypted() {
+		t.Error("Encrypted returned false")
+	}
+
+	dec, err := vars.Decrypt(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m := dec.Map(); m["PLAIN"] != "1" || m["SECRET"] != "s3cret" {
+		t.Errorf("got %v", m)
+	}
+	if vars[1].Value != token {
+		t.Error("Decrypt modified its receiver")
+	}
+
+	_, err = vars.Decrypt(newIdentity(t))
+	if err == nil || err.Error() != "line 2: SECRET: "+ErrDecrypt.Error() {
+		t.Errorf("got error %v", err)
+	}
+	if _, err := vars.Decrypt(nil); err == nil {
+		t.Error("Decrypt with nil identity succeeded")
+	}
+}
+
+func TestReencrypt(t *testing.T) {
+	oldID, newID := newIdentity(t), newIdentity(t)
+	a, _ := oldID.Recipient().Encrypt("A", "a-value")
+	b, _ := oldID.Recipient().Encrypt("B", "b-value")
+	data := "# comment\nA=" + a + "\nPLAIN=x # note\nexport B=\"" + b + "\"\n"
+
+	out, n, err := Reencrypt([]byte(data), oldID, newID.Recipient())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Errorf("got %d rewritten, want 2", n)
+	}
+	if !strings.HasPrefix(string(out), "# comment\nA=ENC[v1:") || !strings.Contains(string(out), "\nPLAIN=x # note\nexport B=\"ENC[v1:") {
+		t.Errorf("surrounding text changed: %q", out)
+	}
+
+	vars, err := Parse(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dec, err := vars.Decrypt(newID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m := dec.Map(); m["A"] != "a-value" || m["B"] != "b-value" {
+		t.Errorf("got %v", m)
+	}
+
+	if _, _, err := Reencrypt([]byte(data), newID, oldID.Recipient()); err == nil {
+		t.Error("Reencrypt with wrong key succeeded")
+	}
+}
@@ -0,0 +1,670 @@
+// Package ebextensions parses, validates, and generates Elastic Beanstalk
+// configuration files (the .ebextensions/*.config files in a source bundle).
+//
+// Configuration files may be written in YAML or JSON. Structural errors,
+// such as misspelled keys or values of the wrong type, are reported with the
+// file and line where they occur, so they can be caught before a bundle is
+// deployed.
+//
+// To generate a configuration file from Go, build a Config with NewConfig
+// and its Add* methods, and render it with Marshal or WriteFile.
+//
+// See
+// http://docs.aws.amazon.com/elasticbeanstalk/latest/dg/ebextensions.html.
+package ebextensions
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/sqs/go-elasticbeanstalk/elasticbeanstalk"
+	"gopkg.in/yaml.v3"
+)
+
+// Dir is the name of the directory in a source bundle that holds
+// configuration files.
+const Dir = ".ebextensions"
+
+// A Config is a parsed configuration file.
+type Config struct {
+	// Filename is the name of the file the config was parsed from, if any.
+	Filename string
+
+	OptionSettings elasticbeanstalk.ConfigurationOptionSettings
+
+	// Packages maps each package manager (such as "yum") to the packages to
+	// install with it and their versions. An empty version list means the
+	// latest version.
+	Packages map[string]map[string][]string
+
+	// Sources maps target directories to archive URLs to extract into them.
+	Sources map[string]string
+
+	// Files maps absolute paths to files to create on instances.
+	Files map[string]*File
+
+	Commands          map[string]*Command
+	ContainerCommands map[string]*Command
+
+	// Resources holds AWS CloudFormation resource definitions, keyed on
+	// logical name.
+	Resources map[string]interface{}
+
+	// Other holds the remaining top-level sections (users, groups, services,
+	// and Outputs), which this package validates as mappings but doesn't
+	// otherwise interpret.
+	Other map[string]interface{}
+}
+
+// A File is an entry in the files section of a config.
+type File struct {
+	Content        string `yaml:"content,omitempty"`
+	Source         string `yaml:"source,omitempty"`
+	Encoding       string `yaml:"encoding,omitempty"`
+	Owner          string `yaml:"owner,omitempty"`
+	Group          string `yaml:"group,omitempty"`
+	Mode           string `yaml:"mode,omitempty"`
+	Authentication string `yaml:"authentication,omitempty"`
+}
+
+// A Command is an entry in the commands or container_commands section of a
+// config.
+type Command struct {
+	// Command is the command to run. If it has a single element, it is run
+	// by a shell; otherwise it is run directly as an argument list.
+	Command []string
+
+	Env          map[string]string
+	Cwd          string
+	Test         string
+	IgnoreErrors bool
+
+	// WaitAfterCompletion (commands only) is the number of seconds to wait
+	// after the command completes, if set.
+	WaitAfterCompletion *int
+
+	// LeaderOnly (container_commands only) runs the command on a single
+	// instance.
+	LeaderOnly bool
+}
+
+// An Error is a structural error in a configuration file.
+type Error struct {
+	File   string
+	Line   int
+	Column int
+	Msg    string
+}
+
+func (e *Error) Error() string {
+	switch {
+	case e.Line == 0:
+		return fmt.Sprintf("%s: %s", e.File, e.Msg)
+	case e.Column == 0:
+		return fmt.Sprintf("%s:%d: %s", e.File, e.Line, e.Msg)
+	default:
+		return fmt.Sprintf("%s:%d:%d: %s", e.File, e.Line, e.Column, e.Msg)
+	}
+}
+
+// An ErrorList is a list of errors found in one or more configuration files.
+type ErrorList []*Error
+
+func (l ErrorList) Error() string {
+	msgs := make([]string, len(l))
+	for i, e := range l {
+		msgs[i] = e.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// ParseDir parses all configuration files in the .ebextensions directory of
+// the source bundle directory dir, in lexical filename order (which is the
+// order Elastic Beanstalk processes them in). It is not an error for the
+// directory not to exist.
+//
+// If any file has errors, ParseDir returns the configs that were parsed
+// along with an ErrorList of all errors.
+func ParseDir(dir string) ([]*Config, error) {
+	files, err := filepath.Glob(filepath.Join(dir, Dir, "*.config"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	var configs []*Config
+	var errs ErrorList
+	for _, file := range files {
+		c, err := ParseFile(file)
+		if err != nil {
+			el, ok := err.(ErrorList)
+			if !ok {
+				return nil, err
+			}
+			errs = append(errs, el...)
+		}
+		if c != nil {
+			configs = append(configs, c)
+		}
+	}
+	if len(errs) > 0 {
+		return configs, errs
+	}
+	return configs, nil
+}
+
+// ParseFile parses the named configuration file.
+func ParseFile(filename string) (*Config, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(filename, data)
+}
+
+// Parse parses the YAML or JSON configuration file data. The filename is
+// used in error messages. If the data has errors, Parse returns the parts of
+// the config it could parse along with an ErrorList.
+func Parse(filename string, data []byte) (*Config, error) {
+	p := &parser{file: filename}
+	c := &Config{Filename: filename}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		p.errs = append(p.errs, yamlError(filename, err))
+		return nil, p.errs
+	}
+	if len(doc.Content) > 0 {
+		p.config(c, doc.Content[0])
+	}
+	if len(p.errs) > 0 {
+		return c, p.errs
+	}
+	return c, nil
+}
+
+var yamlLineErr = regexp.MustCompile(`^yaml: line (\d+): (.*)$`)
+
+// yamlError converts a YAML syntax error to an *Error.
+func yamlError(filename string, err error) *Error {
+	msg := err.Error()
+	if m := yamlLineErr.FindStringSubmatch(msg); m != nil {
+		line, _ := strconv.Atoi(m[1])
+		return &Error{File: filename, Line: line, Msg: m[2]}
+	}
+	return &Error{File: filename, Msg: strings.TrimPrefix(msg, "yaml: ")}
+}
+
+// MergedOptionSettings returns the option settings of configs merged in
+// order, so that settings in later configs take precedence.
+func MergedOptionSettings(configs []*Config) elasticbeanstalk.ConfigurationOptionSettings {
+	var opts elasticbeanstalk.ConfigurationOptionSettings
+	for _, c := range configs {
+		opts = opts.Merge(c.OptionSettings, elasticbeanstalk.Overwrite)
+	}
+	return opts
+}
+
+type parser struct {
+	file string
+	errs ErrorList
+}
+
+func (p *parser) errorf(n *yaml.Node, format string, args ...interface{}) {
+	p.errs = append(p.errs, &Error{File: p.file, Line: n.Line, Column: n.Column, Msg: fmt.Sprintf(format, args...)})
+}
+
+func deref(n *yaml.Node) *yaml.Node {
+	for n.Kind == yaml.AliasNode {
+		n = n.Alias
+	}
+	return n
+}
+
+func isNull(n *yaml.Node) bool {
+	return n.Kind == yaml.ScalarNode && n.Tag == "!!null"
+}
+
+// pair is a key-value pair of a YAML mapping.
+type pair struct {
+	key   string
+	keyN  *yaml.Node
+	value *yaml.Node
+}
+
+// mapping returns the key-value pairs of n, which must be a mapping (or
+// null, which is treated as an empty mapping). The what argument describes
+// n in error messages.
+func (p *parser) mapping(n *yaml.Node, what string) ([]pair, bool) {
+	n = deref(n)
+	if isNull(n) {
+		return nil, true
+	}
+	if n.Kind != yaml.MappingNode {
+		p.errorf(n, "%s must be a mapping, not %s", what, kindName(n))
+		return nil, false
+	}
+	pairs := make([]pair, 0, len(n.Content)/2)
+	seen := map[string]bool{}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		k, v := deref(n.Content[i]), deref(n.Content[i+1])
+		if k.Kind != yaml.ScalarNode {
+			p.errorf(k, "%s keys must be strings", what)
+			continue
+		}
+		if seen[k.Value] {
+			p.errorf(k, "duplicate key %q in %s", k.Value, what)
+		}
+		seen[k.Value] = true
+		pairs = append(pairs, pair{key: k.Value, keyN: k, value: v})
+	}
+	return pairs, true
+}
+
+// scalar returns the string value of n, which must be a scalar.
+func (p *parser) scalar(n *yaml.Node, what string) (string, bool) {
+	if n.Kind != yaml.ScalarNode {
+		p.errorf(n, "%s must be a scalar value, not %s", what, kindName(n))
+		return "", false
+	}
+	if isNull(n) {
+		return "", true
+	}
+	return n.Value, true
+}
+
+func (p *parser) bool(n *yaml.Node, what string) bool {
+	var b bool
+	if n.Kind != yaml.ScalarNode || n.Decode(&b) != nil {
+		// Elastic Beanstalk also accepts the strings "true" and "false".
+		if v := strings.ToLower(n.Value); v == "true" || v == "false" {
+			return v == "true"
+		}
+		p.errorf(n, "%s must be true or false", what)
+	}
+	return b
+}
+
+func kindName(n *yaml.Node) string {
+	switch n.Kind {
+	case yaml.MappingNode:
+		return "a mapping"
+	case yaml.SequenceNode:
+		return "a list"
+	case yaml.ScalarNode:
+		return "a scalar value"
+	default:
+		return "an unknown node"
+	}
+}
+
+// unknownKey reports an unknown key k, suggesting the closest known key if
+// it looks like a typo.
+func (p *parser) unknownKey(k *yaml.Node, what string, known []string) {
+	best, bestDist := "", 3
+	for _, kk := range known {
+		if d := editDistance(strings.ToLower(k.Value), strings.ToLower(kk)); d < bestDist {
+			best, bestDist = kk, d
+		}
+	}
+	if best != "" {
+		p.errorf(k, "unknown key %q in %s (did you mean %q?)", k.Value, what, best)
+		return
+	}
+	p.errorf(k, "unknown key %q in %s", k.Value, what)
+}
+
+var topLevelKeys = []string{
+	"option_settings", "packages", "sources", "files", "users", "groups",
+	"commands", "container_commands", "services", "Resources", "Outputs",
+}
+
+func (p *parser) config(c *Config, n *yaml.Node) {
+	pairs, ok := p.mapping(n, "config file")
+	if !ok {
+		return
+	}
+	for _, kv := range pairs {
+		switch kv.key {
+		case "option_settings":
+			c.OptionSettings = p.optionSettings(kv.value)
+		case "packages":
+			c.Packages = p.packages(kv.value)
+		case "sources":
+			c.Sources = p.stringMap(kv.value, "sources")
+		case "files":
+			c.Files = p.files(kv.value)
+		case "commands":
+			c.Commands = p.commands(kv.value, "commands")
+		case "container_commands":
+			c.ContainerCommands = p.commands(kv.value, "container_commands")
+		case "Resources":
+			c.Resources = p.resources(kv.value)
+		case "users", "groups", "services", "Outputs":
+			if _, ok := p.mapping(kv.value, kv.key); ok {
+				var v interface{}
+				if err := kv.value.Decode(&v); err != nil {
+					p.errorf(kv.value, "%s", err)
+					continue
+				}
+				if c.Other == nil {
+					c.Other = map[string]interface{}{}
+				}
+				c.Other[kv.key] = v
+			}
+		default:
+			p.unknownKey(kv.keyN, "config file", topLevelKeys)
+		}
+	}
+}
+
+// optionSettings parses option_settings in either of its two forms: a
+// mapping of namespaces to mappings of option names to values, or a list
+// of mappings with namespace, option_name, value, and (optionally)
+// resource_name keys.
+func (p *parser) optionSettings(n *yaml.Node) elasticbeanstalk.ConfigurationOptionSettings {
+	var opts elasticbeanstalk.ConfigurationOptionSettings
+	switch n.Kind {
+	case yaml.SequenceNode:
+		for _, item := range n.Content {
+			item = deref(item)
+			pairs, ok := p.mapping(item, "option_settings entry")
+			if !ok {
+				continue
+			}
+			var o elasticbeanstalk.ConfigurationOptionSetting
+			var haveNamespace, haveName bool
+			for _, kv := range pairs {
+				switch kv.key {
+				case "namespace":
+					o.Namespace, _ = p.scalar(kv.value, "namespace")
+					haveNamespace = true
+				case "option_name":
+					o.OptionName, _ = p.scalar(kv.value, "option_name")
+					haveName = true
+				case "resource_name":
+					o.ResourceName, _ = p.scalar(kv.value, "resource_name")
+				case "value":
+					o.Value, _ = p.scalar(kv.value, "value")
+				default:
+					p.unknownKey(kv.keyN, "option_settings entry", []string{"namespace", "option_name", "resource_name", "value"})
+				}
+			}
+			if !haveName {
+				p.errorf(item, "option_settings entry is missing option_name")
+				continue
+			}
+			if !haveNamespace {
+				// Elastic Beanstalk treats entries without a namespace as
+				// environment variables.
+				o.Namespace = elasticbeanstalk.NamespaceApplicationEnvironment
+			}
+			opts = append(opts, o)
+		}
+	default:
+		namespaces, ok := p.mapping(n, "option_settings")
+		if !ok {
+			return nil
+		}
+		for _, ns := range namespaces {
+			options, ok := p.mapping(ns.value, "namespace "+ns.key)
+			if !ok {
+				continue
+			}
+			for _, o := range options {
+				v, _ := p.scalar(o.value, "value of "+o.key)
+				opts = append(opts, elasticbeanstalk.ConfigurationOptionSetting{Namespace: ns.key, OptionName: o.key, Value: v})
+			}
+		}
+	}
+	for _, o := range opts {
+		if o.Namespace == "" {
+			p.errorf(n, "option %q has an empty namespace", o.OptionName)
+		}
+	}
+	return opts
+}
+
+var packageManagers = []string{"apt", "msi", "python", "rpm", "rubygems", "yum"}
+
+func (p *parser) packages(n *yaml.Node) map[string]map[string][]string {
+	managers, ok := p.mapping(n, "packages")
+	if !ok {
+		return nil
+	}
+	m := map[string]map[string][]string{}
+	for _, mgr := range managers {
+		if !contains(packageManagers, mgr.key) {
+			p.unknownKey(mgr.keyN, "packages", packageManagers)
+			continue
+		}
+		pkgs, ok := p.mapping(mgr.value, "packages."+mgr.key)
+		if !ok {
+			continue
+		}
+		m[mgr.key] = map[string][]string{}
+		for _, pkg := range pkgs {
+			var versions []string
+			switch pkg.value.Kind {
+			case yaml.SequenceNode:
+				for _, v := range pkg.value.Content {
+					if s, ok := p.scalar(deref(v), "package version"); ok {
+						versions = append(versions, s)
+					}
+				}
+			default:
+				if s, ok := p.scalar(pkg.value, "package version"); ok && s != "" {
+					versions = []string{s}
+				}
+			}
+			m[mgr.key][pkg.key] = versions
+		}
+	}
+	return m
+}
+
+func (p *parser) stringMap(n *yaml.Node, what string) map[string]string {
+	pairs, ok := p.mapping(n, what)
+	if !ok {
+		return nil
+	}
+	m := make(map[string]string, len(pairs))
+	for _, kv := range pairs {
+		m[kv.key], _ = p.scalar(kv.value, what+" value")
+	}
+	return m
+}
+
+var (
+	fileKeys    = []string{"content", "source", "encoding", "owner", "group", "mode", "authentication"}
+	octalMode   = regexp.MustCompile(`^[0-7]{6}$`)
+	windowsPath = regexp.MustCompile(`^[A-Za-z]:\\`)
+	encodings   = []string{"plain", "base64"}
+)
+
+func (p *parser) files(n *yaml.Node) map[string]*File {
+	paths, ok := p.mapping(n, "files")
+	if !ok {
+		return nil
+	}
+	m := map[string]*File{}
+	for _, path := range paths {
+		if !strings.HasPrefix(path.key, "/") && !windowsPath.MatchString(path.key) {
+			p.errorf(path.keyN, "file path %q must be absolute", path.key)
+		}
+		fields, ok := p.mapping(path.value, "file "+path.key)
+		if !ok {
+			continue
+		}
+		f := &File{}
+		for _, kv := range fields {
+			v, _ := p.scalar(kv.value, kv.key)
+			switch kv.key {
+			case "content":
+				f.Content = v
+			case "source":
+				f.Source = v
+			case "encoding":
+				f.Encoding = v
+				if !contains(encodings, v) {
+					p.errorf(kv.value, "encoding must be plain or base64, not %q", v)
+				}
+			case "owner":
+				f.Owner = v
+			case "group":
+				f.Group = v
+			case "mode":
+				f.Mode = v
+				if !octalMode.MatchString(v) {
+					p.errorf(kv.value, "mode must be a 6-digit octal string (such as \"000644\"), not %q", v)
+				}
+			case "authentication":
+				f.Authentication = v
+			default:
+				p.unknownKey(kv.keyN, "file "+path.key, fileKeys)
+			}
+		}
+		switch {
+		case f.Content == "" && f.Source == "":
+			p.errorf(path.keyN, "file %s must have content or source", path.key)
+		case f.Content != "" && f.Source != "":
+			p.errorf(path.keyN, "file %s must not have both content and source", path.key)
+		}
+		m[path.key] = f
+	}
+	return m
+}
+
+func (p *parser) commands(n *yaml.Node, section string) map[string]*Command {
+	names, ok := p.mapping(n, section)
+	if !ok {
+		return nil
+	}
+	known := []string{"command", "env", "cwd", "test", "ignoreErrors"}
+	if section == "commands" {
+		known = append(known, "waitAfterCompletion")
+	} else {
+		known = append(known, "leader_only")
+	}
+
+	m := map[string]*Command{}
+	for _, name := range names {
+		what := section + "." + name.key
+		fields, ok := p.mapping(name.value, what)
+		if !ok {
+			continue
+		}
+		c := &Command{}
+		for _, kv := range fields {
+			switch kv.key {
+			case "command":
+				if kv.value.Kind == yaml.SequenceNode {
+					for _, arg := range kv.value.Content {
+						if s, ok := p.scalar(deref(arg), "command argument"); ok {
+							c.Command = append(c.Command, s)
+						}
+					}
+				} else if s, ok := p.scalar(kv.value, "command"); ok {
+					c.Command = []string{s}
+				}
+			case "env":
+				c.Env = p.stringMap(kv.value, what+".env")
+			case "cwd":
+				c.Cwd, _ = p.scalar(kv.value, "cwd")
+			case "test":
+				c.Test, _ = p.scalar(kv.value, "test")
+			case "ignoreErrors":
+				c.IgnoreErrors = p.bool(kv.value, "ignoreErrors")
+			case "waitAfterCompletion":
+				if section != "commands" {
+					p.unknownKey(kv.keyN, what, known)
+					continue
+				}
+				var secs int
+				if kv.value.Decode(&secs) != nil {
+					p.errorf(kv.value, "waitAfterCompletion must be a number of seconds")
+					continue
+				}
+				c.WaitAfterCompletion = &secs
+			case "leader_only":
+				if section != "container_commands" {
+					p.unknownKey(kv.keyN, what, known)
+					continue
+				}
+				c.LeaderOnly = p.bool(kv.value, "leader_only")
+			default:
+				p.unknownKey(kv.keyN, what, known)
+			}
+		}
+		if len(c.Command) == 0 || c.Command[0] == "" {
+			p.errorf(name.keyN, "%s is missing command", what)
+		}
+		m[name.key] = c
+	}
+	return m
+}
+
+func (p *parser) resources(n *yaml.Node) map[string]interface{} {
+	names, ok := p.mapping(n, "Resources")
+	if !ok {
+		return nil
+	}
+	m := map[string]interface{}{}
+	for _, name := range names {
+		fields, ok := p.mapping(name.value, "resource "+name.key)
+		if !ok {
+			continue
+		}
+		hasType := false
+		for _, kv := range fields {
+			if kv.key == "Type" {
+				hasType = true
+			}
+		}
+		if !hasType {
+			p.errorf(name.keyN, "resource %s is missing Type", name.key)
+		}
+		var v interface{}
+		if err := name.value.Decode(&v); err != nil {
+			p.errorf(name.value, "%s", err)
+			continue
+		}
+		m[name.key] = v
+	}
+	return m
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
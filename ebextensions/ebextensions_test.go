@@ -0,0 +1,180 @@
+package ebextensions
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kr/pretty"
+	"github.com/sqs/go-elasticbeanstalk/elasticbeanstalk"
+)
+
+func TestParse_optionSettings(t *testing.T) {
+	tests := map[string]string{
+		"list": `
+option_settings:
+  - namespace: aws:autoscaling:asg
+    option_name: MinSize
+    value: 2
+  - namespace: aws:autoscaling:scheduledaction
+    resource_name: ScaleUp
+    option_name: MinSize
+    value: "4"
+  - option_name: FOO
+    value: bar
+`,
+		"map": `
+option_settings:
+  aws:autoscaling:asg:
+    MinSize: 2
+`,
+		"json": `{"option_settings": [{"namespace": "aws:autoscaling:asg", "option_name": "MinSize", "value": "2"}]}`,
+	}
+	want := map[string]elasticbeanstalk.ConfigurationOptionSettings{
+		"list": {
+			{Namespace: "aws:autoscaling:asg", OptionName: "MinSize", Value: "2"},
+			{Namespace: "aws:autoscaling:scheduledaction", ResourceName: "ScaleUp", OptionName: "MinSize", Value: "4"},
+			{Namespace: "aws:elasticbeanstalk:application:environment", OptionName: "FOO", Value: "bar"},
+		},
+		"map": {
+			{Namespace: "aws:autoscaling:asg", OptionName: "MinSize", Value: "2"},
+		},
+		"json": {
+			{Namespace: "aws:autoscaling:asg", OptionName: "MinSize", Value: "2"},
+		},
+	}
+	for name, data := range tests {
+		c, err := Parse(name+".config", []byte(data))
+		if err != nil {
+			t.Errorf("%s: Parse returned error: %v", name, err)
+			continue
+		}
+		if !reflect.DeepEqual(c.OptionSettings, want[name]) {
+			t.Errorf("%s: got %# v, want %# v", name, pretty.Formatter(c.OptionSettings), pretty.Formatter(want[name]))
+		}
+	}
+}
+
+func TestParse_sections(t *testing.T) {
+	data := `
+packages:
+  yum:
+    git: []
+    libmemcached: 0.31
+files:
+  /etc/app.conf:
+    mode: "000644"
+    owner: root
+    content: |
+      x = 1
+commands:
+  01_hello:
+    command: echo hello
+    ignoreErrors: true
+container_commands:
+  02_migrate:
+    command: [./migrate, up]
+    leader_only: true
+Resources:
+  Queue:
+    Type: AWS::SQS::Queue
+`
+	c, err := Parse("a.config", []byte(data))
+	if err != nil {
+		t.Fatalf("Parse returned error: %v", err)
+	}
+	if want := map[string]map[string][]string{"yum": {"git": nil, "libmemcached": {"0.31"}}}; !reflect.DeepEqual(c.Packages, want) {
+		t.Errorf("got packages %v, want %v", c.Packages, want)
+	}
+	if want := (&File{Mode: "000644", Owner: "root", Content: "x = 1\n"}); !reflect.DeepEqual(c.Files["/etc/app.conf"], want) {
+		t.Errorf("got file %+v, want %+v", c.Files["/etc/app.conf"], want)
+	}
+	if want := (&Command{Command: []string{"echo hello"}, IgnoreErrors: true}); !reflect.DeepEqual(c.Commands["01_hello"], want) {
+		t.Errorf("got command %+v, want %+v", c.Commands["01_hello"], want)
+	}
+	if want := (&Command{Command: []string{"./migrate", "up"}, LeaderOnly: true}); !reflect.DeepEqual(c.ContainerCommands["02_migrate"], want) {
+		t.Errorf("got container command %+v, want %+v", c.ContainerCommands["02_migrate"], want)
+	}
+	if _, present := c.Resources["Queue"]; !present {
+		t.Error("resource Queue is missing")
+	}
+}
+
+func TestParse_errors(t *testing.T) {
+	data := `option_setings:
+  aws:autoscaling:asg:
+    MinSize: 2
+files:
+  etc/app.conf:
+    mode: 644
+    content: x
+commands:
+  01_hello:
+    comand: echo hello
+container_commands:
+  02_x:
+    command: x
+    waitAfterCompletion: 1
+Resources:
+  Queue:
+    Properties: {}
+`
+	_, err := Parse("bad.config", []byte(data))
+	if err == nil {
+		t.Fatal("Parse returned no error")
+	}
+	want := []string{
+		`bad.config:1:1: unknown key "option_setings" in config file (did you mean "option_settings"?)`,
+		`bad.config:5:3: file path "etc/app.conf" must be absolute`,
+		`bad.config:6:11: mode must be a 6-digit octal string (such as "000644"), not "644"`,
+		`bad.config:10:5: unknown key "comand" in commands.01_hello (did you mean "command"?)`,
+		`bad.config:9:3: commands.01_hello is missing command`,
+		`bad.config:14:5: unknown key "waitAfterCompletion" in container_commands.02_x`,
+		`bad.config:16:3: resource Queue is missing Type`,
+	}
+	if got := strings.Split(err.Error(), "\n"); !reflect.DeepEqual(got, want) {
+		t.Errorf("got errors:\n%s\n\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestParse_syntaxError(t *testing.T) {
+	_, err := Parse("bad.config", []byte("option_settings:\n\t- x\n"))
+	el, ok := err.(ErrorList)
+	if !ok || len(el) != 1 || el[0].Line != 2 {
+		t.Errorf("got error %#v, want a single error on line 2", err)
+	}
+}
+
+func TestParseDir(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, Dir), 0700); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"01.config": "option_settings:\n  aws:autoscaling:asg:\n    MinSize: 1\n    MaxSize: 2\n",
+		"02.config": "option_settings:\n  aws:autoscaling:asg:\n    MinSize: 3\n",
+		"notes.txt": "ignored",
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, Dir, name), []byte(data), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	configs, err := ParseDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(configs) != 2 {
+		t.Fatalf("got %d configs, want 2", len(configs))
+	}
+	want := elasticbeanstalk.ConfigurationOptionSettings{
+		{Namespace: "aws:autoscaling:asg", OptionName: "MinSize", Value: "3"},
+		{Namespace: "aws:autoscaling:asg", OptionName: "MaxSize", Value: "2"},
+	}
+	if got := MergedOptionSettings(configs); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %# v, want %# v", pretty.Formatter(got), pretty.Formatter(want))
+	}
+}
@@ -0,0 +1,172 @@
+package ebextensions
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/sqs/go-elasticbeanstalk/elasticbeanstalk"
+	"gopkg.in/yaml.v3"
+)
+
+// NewConfig returns an empty config, for building a configuration file with
+// the Add* methods and rendering it with Marshal.
+func NewConfig() *Config {
+	return &Config{}
+}
+
+// AddOptions appends the option settings produced by each builder.
+func (c *Config) AddOptions(builders ...elasticbeanstalk.OptionSettingsBuilder) *Config {
+	for _, b := range builders {
+		c.OptionSettings = append(c.OptionSettings, b.OptionSettings()...)
+	}
+	return c
+}
+
+// SetOption sets the option identified by key to value.
+func (c *Config) SetOption(key elasticbeanstalk.OptionKey, value string) *Config {
+	c.OptionSettings.Set(key, value)
+	return c
+}
+
+// AddPackage adds a package to install with the named package manager
+// (such as "yum"). If no versions are given, the latest version is
+// installed.
+func (c *Config) AddPackage(manager, name string, versions ...string) *Config {
+	if c.Packages == nil {
+		c.Packages = map[string]map[string][]string{}
+	}
+	if c.Packages[manager] == nil {
+		c.Packages[manager] = map[string][]string{}
+	}
+	c.Packages[manager][name] = versions
+	return c
+}
+
+// AddFile adds a file to create at the absolute path on instances.
+func (c *Config) AddFile(path string, f *File) *Config {
+	if c.Files == nil {
+		c.Files = map[string]*File{}
+	}
+	c.Files[path] = f
+	return c
+}
+
+// AddCommand adds a command to run on instances before the application is
+// set up. Commands run in lexical order of their names.
+func (c *Config) AddCommand(name string, cmd *Command) *Config {
+	if c.Commands == nil {
+		c.Commands = map[string]*Command{}
+	}
+	c.Commands[name] = cmd
+	return c
+}
+
+// AddContainerCommand adds a command to run after the application is set up
+// but before it is deployed. Container commands run in lexical order of
+// their names.
+func (c *Config) AddContainerCommand(name string, cmd *Command) *Config {
+	if c.ContainerCommands == nil {
+		c.ContainerCommands = map[string]*Command{}
+	}
+	c.ContainerCommands[name] = cmd
+	return c
+}
+
+// configYAML is the YAML representation of a Config.
+type configYAML struct {
+	OptionSettings    []optionSettingYAML            `yaml:"option_settings,omitempty"`
+	Packages          map[string]map[string][]string `yaml:"packages,omitempty"`
+	Sources           map[string]string              `yaml:"sources,omitempty"`
+	Files             map[string]*File               `yaml:"files,omitempty"`
+	Commands          map[string]*commandYAML        `yaml:"commands,omitempty"`
+	ContainerCommands map[string]*commandYAML        `yaml:"container_commands,omitempty"`
+	Resources         map[string]interface{}         `yaml:"Resources,omitempty"`
+	Other             map[string]interface{}         `yaml:",inline"`
+}
+
+type optionSettingYAML struct {
+	Namespace    string `yaml:"namespace"`
+	ResourceName string `yaml:"resource_name,omitempty"`
+	OptionName   string `yaml:"option_name"`
+	Value        string `yaml:"value"`
+}
+
+type commandYAML struct {
+	Command             interface{}       `yaml:"command"`
+	Env                 map[string]string `yaml:"env,omitempty"`
+	Cwd                 string            `yaml:"cwd,omitempty"`
+	Test                string            `yaml:"test,omitempty"`
+	IgnoreErrors        bool              `yaml:"ignoreErrors,omitempty"`
+	WaitAfterCompletion *int              `yaml:"waitAfterCompletion,omitempty"`
+	LeaderOnly          bool              `yaml:"leader_only,omitempty"`
+}
+
+func commandsYAML(cmds map[string]*Command) map[string]*commandYAML {
+	if len(cmds) == 0 {
+		return nil
+	}
+	m := make(map[string]*commandYAML, len(cmds))
+	for name, c := range cmds {
+		cy := &commandYAML{
+			Env:                 c.Env,
+			Cwd:                 c.Cwd,
+			Test:                c.Test,
+			IgnoreErrors:        c.IgnoreErrors,
+			WaitAfterCompletion: c.WaitAfterCompletion,
+			LeaderOnly:          c.LeaderOnly,
+		}
+		if len(c.Command) == 1 {
+			cy.Command = c.Command[0]
+		} else {
+			cy.Command = c.Command
+		}
+		m[name] = cy
+	}
+	return m
+}
+
+// Marshal renders c as a YAML configuration file. The output is parsed and
+// validated before it is returned, so an error is returned if c would
+// produce an invalid configuration file (for example, if a file has neither
+// content nor source).
+func (c *Config) Marshal() ([]byte, error) {
+	cy := configYAML{
+		Packages:          c.Packages,
+		Sources:           c.Sources,
+		Files:             c.Files,
+		Commands:          commandsYAML(c.Commands),
+		ContainerCommands: commandsYAML(c.ContainerCommands),
+		Resources:         c.Resources,
+		Other:             c.Other,
+	}
+	for _, o := range c.OptionSettings {
+		cy.OptionSettings = append(cy.OptionSettings, optionSettingYAML{
+			Namespace:    o.Namespace,
+			ResourceName: o.ResourceName,
+			OptionName:   o.OptionName,
+			Value:        o.Value,
+		})
+	}
+
+	data, err := yaml.Marshal(&cy)
+	if err != nil {
+		return nil, err
+	}
+	name := c.Filename
+	if name == "" {
+		name = "generated config"
+	}
+	if _, err := Parse(name, data); err != nil {
+		return nil, fmt.Errorf("generated config is invalid:\n%s", err)
+	}
+	return data, nil
+}
+
+// WriteFile renders c with Marshal and writes it to the named file.
+func (c *Config) WriteFile(filename string) error {
+	data, err := c.Marshal()
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, data, 0644)
+}
@@ -0,0 +1,43 @@
+package ebextensions
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kr/pretty"
+	"github.com/sqs/go-elasticbeanstalk/elasticbeanstalk"
+)
+
+func TestConfig_Marshal(t *testing.T) {
+	wait := 5
+	c := NewConfig().
+		AddOptions(&elasticbeanstalk.AutoScalingGroupOptions{MinSize: elasticbeanstalk.Int(2)}).
+		SetOption(elasticbeanstalk.EnvKey("FOO"), "0123").
+		AddPackage("yum", "git").
+		AddPackage("yum", "libmemcached", "0.31").
+		AddFile("/etc/app.conf", &File{Mode: "000644", Content: "x = 1\n"}).
+		AddCommand("01_hello", &Command{Command: []string{"echo hello"}, WaitAfterCompletion: &wait}).
+		AddContainerCommand("02_migrate", &Command{Command: []string{"./migrate", "up"}, LeaderOnly: true})
+
+	data, err := c.Marshal()
+	if err != nil {
+		t.Fatalf("Marshal returned error: %v", err)
+	}
+
+	// The rendered config must parse back to the same config.
+	got, err := Parse("generated.config", data)
+	if err != nil {
+		t.Fatalf("Parse returned error: %v\n\n%s", err, data)
+	}
+	got.Filename = ""
+	if !reflect.DeepEqual(got, c) {
+		t.Errorf("round trip: got %# v, want %# v\n\n%s", pretty.Formatter(got), pretty.Formatter(c), data)
+	}
+}
+
+func TestConfig_Marshal_invalid(t *testing.T) {
+	c := NewConfig().AddFile("/etc/empty", &File{})
+	if _, err := c.Marshal(); err == nil {
+		t.Error("Marshal of file without content or source returned no error")
+	}
+}
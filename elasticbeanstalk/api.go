@@ -0,0 +1,23 @@
+package elasticbeanstalk
+
+// API is the set of Elastic Beanstalk operations implemented by Client.
+// Code that depends on API instead of *Client can be tested in isolation by
+// substituting a fake implementation, such as ebmock.Client.
+type API interface {
+	CheckDNSAvailability(params *CheckDNSAvailabilityParams) (*CheckDNSAvailabilityResult, error)
+	CreateApplicationVersion(params *CreateApplicationVersionParams) error
+	CreateEnvironment(params *CreateEnvironmentParams) error
+	DeleteApplicationVersion(params *DeleteApplicationVersionParams) error
+	DescribeApplicationVersions(params *DescribeApplicationVersionsParams) (*DescribeApplicationVersionsResult, error)
+	DescribeConfigurationSettings(params *DescribeConfigurationSettingsParams) (ConfigurationSettings, error)
+	DescribeEnvironmentHealth(params *DescribeEnvironmentHealthParams) (*EnvironmentHealth, error)
+	DescribeEnvironments(params *DescribeEnvironmentsParams) ([]*EnvironmentDescription, error)
+	DescribeEvents(params *DescribeEventsParams) (*DescribeEventsResult, error)
+	RequestEnvironmentInfo(params *RequestEnvironmentInfoParams) error
+	RetrieveEnvironmentInfo(params *RetrieveEnvironmentInfoParams) ([]*EnvironmentInfoDescription, error)
+	SwapEnvironmentCNAMEs(params *SwapEnvironmentCNAMEsParams) error
+	TerminateEnvironment(params *TerminateEnvironmentParams) error
+	UpdateEnvironment(params *UpdateEnvironmentParams) error
+}
+
+var _ API = (*Client)(nil)
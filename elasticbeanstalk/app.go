@@ -1,6 +1,8 @@
 package elasticbeanstalk
 
 import (
+	"fmt"
+
 	"github.com/google/go-querystring/query"
 )
 
@@ -24,3 +26,105 @@ func (c *Client) CreateApplicationVersion(params *CreateApplicationVersionParams
 	}
 	return c.Do("POST", "CreateApplicationVersion", v, nil)
 }
+
+// DescribeApplicationVersionsParams specifies parameters for
+// DescribeApplicationVersions.
+//
+// See
+// http://docs.aws.amazon.com/elasticbeanstalk/latest/api/API_DescribeApplicationVersions.html.
+type DescribeApplicationVersionsParams struct {
+	// ApplicationName, if set, restricts the results to versions of that
+	// application. Otherwise versions of all applications are returned.
+	ApplicationName string `url:",omitempty"`
+
+	// VersionLabels, if set, restricts the results to versions with those
+	// labels.
+	VersionLabels []string `url:"-"`
+
+	MaxRecords int    `url:",omitempty"`
+	NextToken  string `url:",omitempty"`
+}
+
+// ApplicationVersionDescription describes an application version.
+//
+// See
+// http://docs.aws.amazon.com/elasticbeanstalk/latest/api/API_ApplicationVersionDescription.html.
+type ApplicationVersionDescription struct {
+	ApplicationName string
+	DateCreated     Time
+	DateUpdated     Time
+	Description     string `json:",omitempty"`
+	SourceBundle    S3Location
+	Status          string `json:",omitempty"`
+	VersionLabel    string
+}
+
+// S3Location is the location of an object in S3.
+//
+// See
+// http://docs.aws.amazon.com/elasticbeanstalk/latest/api/API_S3Location.html.
+type S3Location struct {
+	S3Bucket string
+	S3Key    string
+}
+
+// DescribeApplicationVersionsResult is a page of application versions
+// returned by DescribeApplicationVersions.
+type DescribeApplicationVersionsResult struct {
+	ApplicationVersions []*ApplicationVersionDescription
+
+	// NextToken, if non-empty, is passed in
+	// DescribeApplicationVersionsParams to get the next page of versions.
+	NextToken string `json:",omitempty"`
+}
+
+// DescribeApplicationVersions returns a page of application versions
+// matching params.
+//
+// See
+// http://docs.aws.amazon.com/elasticbeanstalk/latest/api/API_DescribeApplicationVersions.html.
+func (c *Client) DescribeApplicationVersions(params *DescribeApplicationVersionsParams) (*DescribeApplicationVersionsResult, error) {
+	v, err := query.Values(params)
+	if err != nil {
+		return nil, err
+	}
+	for i, label := range params.VersionLabels {
+		v.Set(fmt.Sprintf("VersionLabels.member.%d", i+1), label)
+	}
+	var o struct {
+		DescribeApplicationVersionsResponse struct {
+			DescribeApplicationVersionsResult DescribeApplicationVersionsResult
+		}
+	}
+	if err := c.Do("GET", "DescribeApplicationVersions", v, &o); err != nil {
+		return nil, err
+	}
+	return &o.DescribeApplicationVersionsResponse.DescribeApplicationVersionsResult, nil
+}
+
+// DeleteApplicationVersionParams specifies parameters for
+// DeleteApplicationVersion.
+//
+// See
+// http://docs.aws.amazon.com/elasticbeanstalk/latest/api/API_DeleteApplicationVersion.html.
+type DeleteApplicationVersionParams struct {
+	ApplicationName string
+	VersionLabel    string
+
+	// DeleteSourceBundle is whether to also delete the version's source
+	// bundle from S3.
+	DeleteSourceBundle bool
+}
+
+// DeleteApplicationVersion deletes an application version. A version that
+// is deployed to an environment can't be deleted.
+//
+// See
+// http://docs.aws.amazon.com/elasticbeanstalk/latest/api/API_DeleteApplicationVersion.html.
+func (c *Client) DeleteApplicationVersion(params *DeleteApplicationVersionParams) error {
+	v, err := query.Values(params)
+	if err != nil {
+		return err
+	}
+	return c.Do("POST", "DeleteApplicationVersion", v, nil)
+}
@@ -2,7 +2,11 @@ package elasticbeanstalk
 
 import (
 	"net/http"
+	"net/url"
+	"reflect"
 	"testing"
+
+	"github.com/kr/pretty"
 )
 
 func TestCreateApplicationVersion(t *testing.T) {
@@ -18,3 +22,93 @@ func TestCreateApplicationVersion(t *testing.T) {
 		t.Errorf("CreateApplicationVersion returned error: %v", err)
 	}
 }
+
+func TestDescribeApplicationVersions(t *testing.T) {
+	setup()
+	defer teardown()
+
+	wantParams := url.Values{
+		"Operation":              []string{"DescribeApplicationVersions"},
+		"ApplicationName":        []string{"app"},
+		"VersionLabels.member.1": []string{"v1"},
+		"VersionLabels.member.2": []string{"v2"},
+		"MaxRecords":             []string{"10"},
+	}
+
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		if p := r.URL.Query(); !reflect.DeepEqual(p, wantParams) {
+			t.Errorf("DescribeApplicationVersions got params %# v, want %# v", pretty.Formatter(p), pretty.Formatter(wantParams))
+		}
+		writeJSON(w, `
+{
+    "DescribeApplicationVersionsResponse": {"DescribeApplicationVersionsResult": {
+        "ApplicationVersions": [
+            {
+                "ApplicationName": "app",
+                "DateCreated": `+floatTime(t, "2014-02-28T00:33:47.684Z")+`,
+                "DateUpdated": `+floatTime(t, "2014-02-28T00:33:47.684Z")+`,
+                "Description": "d",
+                "SourceBundle": {"S3Bucket": "b", "S3Key": "v2-0.zip"},
+                "Status": "PROCESSED",
+                "VersionLabel": "v2"
+            }
+        ],
+        "NextToken": "t"
+    }}
+}
+`)
+	})
+
+	want := &DescribeApplicationVersionsResult{
+		ApplicationVersions: []*ApplicationVersionDescription{
+			{
+				ApplicationName: "app",
+				DateCreated:     mustParseTime(t, "2014-02-28T00:33:47.684Z"),
+				DateUpdated:     mustParseTime(t, "2014-02-28T00:33:47.684Z"),
+				Description:     "d",
+				SourceBundle:    S3Location{S3Bucket: "b", S3Key: "v2-0.zip"},
+				Status:          "PROCESSED",
+				VersionLabel:    "v2",
+			},
+		},
+		NextToken: "t",
+	}
+
+	got, err := client.DescribeApplicationVersions(&DescribeApplicationVersionsParams{ApplicationName: "app", VersionLabels: []string{"v1", "v2"}, MaxRecords: 10})
+	if err != nil {
+		t.Fatalf("DescribeApplicationVersions returned error: %v", err)
+	}
+	for _, v := range append(got.ApplicationVersions, want.ApplicationVersions...) {
+		normTime(&v.DateCreated)
+		normTime(&v.DateUpdated)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DescribeApplicationVersions returned %+v, want %+v", asJSON(t, got), asJSON(t, want))
+	}
+}
+
+func TestDeleteApplicationVersion(t *testing.T) {
+	setup()
+	defer teardown()
+
+	wantParams := url.Values{
+		"Operation":          []string{"DeleteApplicationVersion"},
+		"ApplicationName":    []string{"app"},
+		"VersionLabel":       []string{"v1"},
+		"DeleteSourceBundle": []string{"true"},
+	}
+
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+		if p := r.URL.Query(); !reflect.DeepEqual(p, wantParams) {
+			t.Errorf("DeleteApplicationVersion got params %# v, want %# v", pretty.Formatter(p), pretty.Formatter(wantParams))
+		}
+		writeJSON(w, `{}`)
+	})
+
+	err := client.DeleteApplicationVersion(&DeleteApplicationVersionParams{ApplicationName: "app", VersionLabel: "v1", DeleteSourceBundle: true})
+	if err != nil {
+		t.Errorf("DeleteApplicationVersion returned error: %v", err)
+	}
+}
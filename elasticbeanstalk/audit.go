@@ -0,0 +1,78 @@
+package elasticbeanstalk
+
+import (
+	"encoding/json"
+	"io"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// An AuditRecord describes a mutating operation sent by a Client.
+type AuditRecord struct {
+	// Time is when the operation completed.
+	Time time.Time `json:"time"`
+
+	// Operation is the operation name (such as "UpdateEnvironment").
+	Operation string `json:"operation"`
+
+	// Params are the request parameters. The values of environment
+	// variables are redacted.
+	Params url.Values `json:"params"`
+
+	// RequestID is the AWS request ID of the response, if a response was
+	// received.
+	RequestID string `json:"request_id,omitempty"`
+
+	// Outcome is "success" if the operation succeeded and "error"
+	// otherwise. If it failed, Error holds the error message.
+	Outcome string `json:"outcome"`
+	Error   string `json:"error,omitempty"`
+
+	// AccessKeyID is the AWS access key ID that signed the request.
+	AccessKeyID string `json:"access_key_id"`
+}
+
+// An AuditSink stores AuditRecords. Implementations must be safe for
+// concurrent use.
+type AuditSink interface {
+	Record(rec *AuditRecord) error
+}
+
+// NewJSONLinesAuditSink returns an AuditSink that writes each record to w as
+// a single line of JSON.
+func NewJSONLinesAuditSink(w io.Writer) AuditSink {
+	return &jsonLinesAuditSink{w: w}
+}
+
+type jsonLinesAuditSink struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (s *jsonLinesAuditSink) Record(rec *AuditRecord) error {
+	b, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = s.w.Write(append(b, '\n'))
+	return err
+}
+
+func (c *Client) audit(operation string, params url.Values, requestID string, err error) error {
+	rec := &AuditRecord{
+		Time:        time.Now().UTC(),
+		Operation:   operation,
+		Params:      redactEnv(params),
+		RequestID:   requestID,
+		Outcome:     "success",
+		AccessKeyID: c.Auth.AccessKey,
+	}
+	if err != nil {
+		rec.Outcome = "error"
+		rec.Error = err.Error()
+	}
+	return c.Audit.Record(rec)
+}
@@ -0,0 +1,73 @@
+package elasticbeanstalk
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestClient_Audit(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Amzn-Requestid", "req-"+r.URL.Query().Get("Operation"))
+		if r.URL.Query().Get("EnvironmentName") == "bad" {
+			http.Error(w, "bad", http.StatusBadRequest)
+			return
+		}
+		writeJSON(w, `{}`)
+	})
+
+	var buf bytes.Buffer
+	client.Audit = NewJSONLinesAuditSink(&buf)
+	client.Auth.AccessKey = "AKID"
+
+	if _, err := client.DescribeEnvironments(&DescribeEnvironmentsParams{}); err != nil {
+		t.Errorf("DescribeEnvironments returned error: %v", err)
+	}
+	p := &UpdateEnvironmentParams{EnvironmentName: "env"}
+	p.AddEnv("SECRET", "hunter2")
+	if err := client.UpdateEnvironment(p); err != nil {
+		t.Errorf("UpdateEnvironment returned error: %v", err)
+	}
+	if err := client.UpdateEnvironment(&UpdateEnvironmentParams{EnvironmentName: "bad"}); err == nil {
+		t.Error("UpdateEnvironment returned no error, want error")
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d audit records, want 2 (one per mutating call):\n%s", len(lines), buf.String())
+	}
+	if strings.Contains(buf.String(), "hunter2") {
+		t.Errorf("audit trail contains environment variable value:\n%s", buf.String())
+	}
+
+	var rec AuditRecord
+	if err := json.Unmarshal([]byte(lines[0]), &rec); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Operation != "UpdateEnvironment" || rec.Outcome != "success" || rec.RequestID != "req-UpdateEnvironment" || rec.AccessKeyID != "AKID" {
+		t.Errorf("got audit record %+v", rec)
+	}
+	wantParams := url.Values{
+		"EnvironmentName":                    []string{"env"},
+		"OptionSettings.member.1.Namespace":  []string{"aws:elasticbeanstalk:application:environment"},
+		"OptionSettings.member.1.OptionName": []string{"SECRET"},
+		"OptionSettings.member.1.Value":      []string{"REDACTED"},
+	}
+	if !reflect.DeepEqual(rec.Params, wantParams) {
+		t.Errorf("got audit params %v, want %v", rec.Params, wantParams)
+	}
+
+	if err := json.Unmarshal([]byte(lines[1]), &rec); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Outcome != "error" || rec.Error == "" {
+		t.Errorf("got audit record %+v, want error outcome", rec)
+	}
+}
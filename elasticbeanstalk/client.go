@@ -1,6 +1,7 @@
 package elasticbeanstalk
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -13,21 +14,91 @@ import (
 )
 
 type Client struct {
-	BaseURL    *url.URL
-	Auth       aws.Auth
-	Region     aws.Region
+	BaseURL *url.URL
+	Auth    aws.Auth
+	Region  aws.Region
+
+	// DryRun, if non-nil, puts the client in dry-run mode: mutating
+	// operations (see IsMutating) are recorded in DryRun instead of being
+	// sent. Read-only operations are sent as usual.
+	DryRun *DryRun
+
+	// Audit, if non-nil, receives a record of each mutating operation that
+	// the client sends.
+	Audit AuditSink
+
+	// Limiter, if non-nil, limits the rate at which the client sends
+	// requests. It may be shared by multiple clients.
+	Limiter *Limiter
+
 	httpClient *http.Client
+	ctx        context.Context
 }
 
 func NewClient(httpClient *http.Client) *Client {
 	return &Client{httpClient: httpClient}
 }
 
+// WithContext returns a shallow copy of c that uses ctx for its requests,
+// including while waiting on c.Limiter. It shares c's DryRun, Audit, and
+// Limiter.
+func (c *Client) WithContext(ctx context.Context) *Client {
+	c2 := *c
+	c2.ctx = ctx
+	return &c2
+}
+
+func (c *Client) context() context.Context {
+	if c.ctx == nil {
+		return context.Background()
+	}
+	return c.ctx
+}
+
 func (c *Client) Do(method string, operation string, params url.Values, respData interface{}) error {
+	if !IsMutating(operation) {
+		_, err := c.do(method, operation, params, respData)
+		return err
+	}
+
+	if c.DryRun != nil {
+		c.DryRun.record(operation, params)
+		return nil
+	}
+
+	requestID, err := c.do(method, operation, params, respData)
+	if c.Audit != nil {
+		if auditErr := c.audit(operation, params, requestID, err); auditErr != nil && err == nil {
+			return fmt.Errorf("%s succeeded, but recording it in the audit trail failed: %s", operation, auditErr)
+		}
+	}
+	return err
+}
+
+// do sends the request and decodes the response into respData. It returns
+// the AWS request ID of the response, if any.
+func (c *Client) do(method string, operation string, params url.Values, respData interface{}) (string, error) {
+	if c.Limiter == nil {
+		return c.send(method, operation, params, respData)
+	}
+
+	if err := c.Limiter.Wait(c.context(), operation); err != nil {
+		return "", err
+	}
+	requestID, err := c.send(method, operation, params, respData)
+	if IsThrottling(err) {
+		c.Limiter.Throttled(operation)
+	} else if err == nil {
+		c.Limiter.Succeeded(operation)
+	}
+	return requestID, err
+}
+
+func (c *Client) send(method string, operation string, params url.Values, respData interface{}) (string, error) {
 	url := c.BaseURL.ResolveReference(&url.URL{RawQuery: fmt.Sprintf("Operation=%s&%s", operation, params.Encode())})
-	r, err := http.NewRequest(method, url.String(), nil)
+	r, err := http.NewRequestWithContext(c.context(), method, url.String(), nil)
 	if err != nil {
-		return err
+		return "", err
 	}
 	r.Header.Set("accept", "application/json")
 	r.Header.Set("X-Amz-Date", time.Now().UTC().Format(aws.ISO8601BasicFormat))
@@ -41,24 +112,82 @@ func (c *Client) Do(method string, operation string, params url.Values, respData
 
 	resp, err := httpClient.Do(r)
 	if err != nil {
-		return err
+		return "", err
 	}
 	defer resp.Body.Close()
+	requestID := resp.Header.Get("X-Amzn-Requestid")
 	if resp.StatusCode != 200 {
 		msg, err := ioutil.ReadAll(resp.Body)
 		if err != nil {
-			return err
+			return requestID, err
 		}
-		return fmt.Errorf("http status code %d (%s): %s", resp.StatusCode, http.StatusText(resp.StatusCode), msg)
+		return requestID, newAPIError(resp.StatusCode, requestID, msg)
 	}
 
 	if respData != nil {
 		if err := json.NewDecoder(resp.Body).Decode(respData); err != nil {
-			return err
+			return requestID, err
 		}
 	}
 
-	return nil
+	return requestID, nil
+}
+
+// An APIError is returned by Client methods when the Elastic Beanstalk API
+// responds with a non-200 HTTP status code.
+//
+// See
+// http://docs.aws.amazon.com/elasticbeanstalk/latest/api/CommonErrors.html.
+type APIError struct {
+	StatusCode int
+	RequestID  string
+
+	// Code and Message are the AWS error code (such as "Throttling") and
+	// message, if the response body could be parsed.
+	Code    string
+	Message string
+
+	// Body is the raw response body.
+	Body []byte
+}
+
+func newAPIError(statusCode int, requestID string, body []byte) *APIError {
+	e := &APIError{StatusCode: statusCode, RequestID: requestID, Body: body}
+	var o struct {
+		Error struct {
+			Code    string
+			Message string
+		}
+		RequestId string
+	}
+	if json.Unmarshal(body, &o) == nil {
+		e.Code, e.Message = o.Error.Code, o.Error.Message
+		if e.RequestID == "" {
+			e.RequestID = o.RequestId
+		}
+	}
+	return e
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("http status code %d (%s): %s", e.StatusCode, http.StatusText(e.StatusCode), e.Body)
+}
+
+// throttlingCodes are the AWS error codes that indicate that a request was
+// rejected because the caller exceeded its request rate.
+var throttlingCodes = map[string]bool{
+	"Throttling":                             true,
+	"ThrottlingException":                    true,
+	"RequestLimitExceeded":                   true,
+	"TooManyRequestsException":               true,
+	"ProvisionedThroughputExceededException": true,
+}
+
+// IsThrottling reports whether err is an *APIError that indicates that the
+// request was throttled.
+func IsThrottling(err error) bool {
+	e, ok := err.(*APIError)
+	return ok && (e.StatusCode == http.StatusTooManyRequests || throttlingCodes[e.Code])
 }
 
 // Time is a time.Time whose JSON representation is its floating point
@@ -0,0 +1,89 @@
+package elasticbeanstalk
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// A DryRun records the mutating operations that a Client in dry-run mode
+// would have sent. It is safe for concurrent use.
+type DryRun struct {
+	mu  sync.Mutex
+	ops []*Operation
+}
+
+// An Operation is an API operation recorded by a DryRun.
+type Operation struct {
+	// Name is the operation name (such as "UpdateEnvironment").
+	Name string
+
+	// Params are the request parameters exactly as they would have been sent.
+	Params url.Values
+}
+
+// Encode returns the URL-encoded request parameters of the operation.
+func (o *Operation) Encode() string {
+	return o.Params.Encode()
+}
+
+// RedactedParams returns a copy of the request parameters in which the
+// values of environment variables are replaced with "REDACTED", for
+// displaying the operation.
+func (o *Operation) RedactedParams() url.Values {
+	return redactEnv(o.Params)
+}
+
+// Operations returns the recorded operations, in the order they were
+// made.
+func (d *DryRun) Operations() []*Operation {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]*Operation(nil), d.ops...)
+}
+
+func (d *DryRun) record(operation string, params url.Values) {
+	v := make(url.Values, len(params))
+	for k, vs := range params {
+		v[k] = append([]string(nil), vs...)
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.ops = append(d.ops, &Operation{Name: operation, Params: v})
+}
+
+// readOnlyPrefixes are the prefixes of the names of operations that don't
+// modify any resources.
+var readOnlyPrefixes = []string{"Check", "Describe", "List", "Retrieve", "Validate"}
+
+// IsMutating reports whether the named operation may create, modify, or
+// delete resources. All operations are assumed to be mutating except for
+// those whose names start with Check, Describe, List, Retrieve, or Validate.
+// (RequestEnvironmentInfo is mutating, since it makes the environment's
+// instances collect their logs.)
+func IsMutating(operation string) bool {
+	for _, p := range readOnlyPrefixes {
+		if strings.HasPrefix(operation, p) {
+			return false
+		}
+	}
+	return true
+}
+
+var optionSettingValueKey = regexp.MustCompile(`^(OptionSettings\.member\.\d+\.)Value$`)
+
+// redactEnv returns a copy of params in which the values of environment
+// variable option settings are replaced with "REDACTED", so that secrets
+// aren't displayed or logged.
+func redactEnv(params url.Values) url.Values {
+	v := make(url.Values, len(params))
+	for k, vs := range params {
+		if m := optionSettingValueKey.FindStringSubmatch(k); m != nil && params.Get(m[1]+"Namespace") == envVarNamespace {
+			v[k] = []string{"REDACTED"}
+			continue
+		}
+		v[k] = append([]string(nil), vs...)
+	}
+	return v
+}
@@ -0,0 +1,89 @@
+package elasticbeanstalk
+
+import (
+	"net/http"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestClient_DryRun(t *testing.T) {
+	setup()
+	defer teardown()
+
+	var ops []string
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		ops = append(ops, r.URL.Query().Get("Operation"))
+		writeJSON(w, `{}`)
+	})
+
+	client.DryRun = &DryRun{}
+	if _, err := client.DescribeEnvironments(&DescribeEnvironmentsParams{}); err != nil {
+		t.Errorf("DescribeEnvironments returned error: %v", err)
+	}
+	p := &UpdateEnvironmentParams{EnvironmentName: "env", VersionLabel: "v1"}
+	p.AddEnv("K", "V")
+	if err := client.UpdateEnvironment(p); err != nil {
+		t.Errorf("UpdateEnvironment returned error: %v", err)
+	}
+
+	if want := []string{"DescribeEnvironments"}; !reflect.DeepEqual(ops, want) {
+		t.Errorf("got operations sent %v, want %v", ops, want)
+	}
+
+	want := []*Operation{
+		{
+			Name: "UpdateEnvironment",
+			Params: url.Values{
+				"EnvironmentName":                    []string{"env"},
+				"VersionLabel":                       []string{"v1"},
+				"OptionSettings.member.1.Namespace":  []string{"aws:elasticbeanstalk:application:environment"},
+				"OptionSettings.member.1.OptionName": []string{"K"},
+				"OptionSettings.member.1.Value":      []string{"V"},
+			},
+		},
+	}
+	if got := client.DryRun.Operations(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got recorded operations %v, want %v", asJSON(t, got), asJSON(t, want))
+	}
+}
+
+func TestOperation_RedactedParams(t *testing.T) {
+	op := &Operation{
+		Name: "UpdateEnvironment",
+		Params: url.Values{
+			"OptionSettings.member.1.Namespace":  []string{"aws:elasticbeanstalk:application:environment"},
+			"OptionSettings.member.1.OptionName": []string{"SECRET"},
+			"OptionSettings.member.1.Value":      []string{"s3cret"},
+			"OptionSettings.member.2.Namespace":  []string{"aws:autoscaling:asg"},
+			"OptionSettings.member.2.OptionName": []string{"MinSize"},
+			"OptionSettings.member.2.Value":      []string{"2"},
+		},
+	}
+	got := op.RedactedParams()
+	if v := got.Get("OptionSettings.member.1.Value"); v != "REDACTED" {
+		t.Errorf("got env var value %q, want REDACTED", v)
+	}
+	if v := got.Get("OptionSettings.member.2.Value"); v != "2" {
+		t.Errorf("got option value %q, want 2", v)
+	}
+	if v := op.Params.Get("OptionSettings.member.1.Value"); v != "s3cret" {
+		t.Errorf("RedactedParams modified the operation's params (got %q)", v)
+	}
+}
+
+func TestIsMutating(t *testing.T) {
+	tests := map[string]bool{
+		"CreateApplicationVersion":      true,
+		"UpdateEnvironment":             true,
+		"DescribeEnvironments":          false,
+		"DescribeConfigurationSettings": false,
+		"RequestEnvironmentInfo":        true,
+		"RetrieveEnvironmentInfo":       false,
+	}
+	for op, want := range tests {
+		if got := IsMutating(op); got != want {
+			t.Errorf("IsMutating(%q) = %v, want %v", op, got, want)
+		}
+	}
+}
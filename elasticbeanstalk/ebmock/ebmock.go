@@ -0,0 +1,194 @@
+// Package ebmock provides a mock implementation of elasticbeanstalk.API that
+// records calls and returns programmable responses.
+package ebmock
+
+import (
+	"sync"
+
+	"github.com/sqs/go-elasticbeanstalk/elasticbeanstalk"
+)
+
+// Client is a mock elasticbeanstalk.API. Each operation calls the
+// corresponding Func field, if set; otherwise it returns an empty result (a
+// nil slice or a pointer to a zero-valued struct) and a nil error. All calls
+// are recorded, whether or not a Func is set.
+//
+// A Client is safe for concurrent use, provided that its Func fields are not
+// modified while it is in use.
+type Client struct {
+	CheckDNSAvailabilityFunc          func(params *elasticbeanstalk.CheckDNSAvailabilityParams) (*elasticbeanstalk.CheckDNSAvailabilityResult, error)
+	CreateApplicationVersionFunc      func(params *elasticbeanstalk.CreateApplicationVersionParams) error
+	CreateEnvironmentFunc             func(params *elasticbeanstalk.CreateEnvironmentParams) error
+	DeleteApplicationVersionFunc      func(params *elasticbeanstalk.DeleteApplicationVersionParams) error
+	DescribeApplicationVersionsFunc   func(params *elasticbeanstalk.DescribeApplicationVersionsParams) (*elasticbeanstalk.DescribeApplicationVersionsResult, error)
+	DescribeConfigurationSettingsFunc func(params *elasticbeanstalk.DescribeConfigurationSettingsParams) (elasticbeanstalk.ConfigurationSettings, error)
+	DescribeEnvironmentHealthFunc     func(params *elasticbeanstalk.DescribeEnvironmentHealthParams) (*elasticbeanstalk.EnvironmentHealth, error)
+	DescribeEnvironmentsFunc          func(params *elasticbeanstalk.DescribeEnvironmentsParams) ([]*elasticbeanstalk.EnvironmentDescription, error)
+	DescribeEventsFunc                func(params *elasticbeanstalk.DescribeEventsParams) (*elasticbeanstalk.DescribeEventsResult, error)
+	RequestEnvironmentInfoFunc        func(params *elasticbeanstalk.RequestEnvironmentInfoParams) error
+	RetrieveEnvironmentInfoFunc       func(params *elasticbeanstalk.RetrieveEnvironmentInfoParams) ([]*elasticbeanstalk.EnvironmentInfoDescription, error)
+	SwapEnvironmentCNAMEsFunc         func(params *elasticbeanstalk.SwapEnvironmentCNAMEsParams) error
+	TerminateEnvironmentFunc          func(params *elasticbeanstalk.TerminateEnvironmentParams) error
+	UpdateEnvironmentFunc             func(params *elasticbeanstalk.UpdateEnvironmentParams) error
+
+	mu    sync.Mutex
+	calls []Call
+}
+
+var _ elasticbeanstalk.API = (*Client)(nil)
+
+// A Call is a recorded call to an operation of Client.
+type Call struct {
+	// Operation is the name of the method that was called (such as
+	// "UpdateEnvironment").
+	Operation string
+
+	// Params is the params argument passed to the method (such as a
+	// *elasticbeanstalk.UpdateEnvironmentParams).
+	Params interface{}
+}
+
+// Calls returns all calls made so far, in order.
+func (c *Client) Calls() []Call {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]Call(nil), c.calls...)
+}
+
+// CallsTo returns all calls made so far to the named operation, in order.
+func (c *Client) CallsTo(operation string) []Call {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var calls []Call
+	for _, call := range c.calls {
+		if call.Operation == operation {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+// Reset discards all recorded calls.
+func (c *Client) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.calls = nil
+}
+
+func (c *Client) record(operation string, params interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.calls = append(c.calls, Call{Operation: operation, Params: params})
+}
+
+func (c *Client) CheckDNSAvailability(params *elasticbeanstalk.CheckDNSAvailabilityParams) (*elasticbeanstalk.CheckDNSAvailabilityResult, error) {
+	c.record("CheckDNSAvailability", params)
+	if c.CheckDNSAvailabilityFunc == nil {
+		return &elasticbeanstalk.CheckDNSAvailabilityResult{}, nil
+	}
+	return c.CheckDNSAvailabilityFunc(params)
+}
+
+func (c *Client) CreateApplicationVersion(params *elasticbeanstalk.CreateApplicationVersionParams) error {
+	c.record("CreateApplicationVersion", params)
+	if c.CreateApplicationVersionFunc == nil {
+		return nil
+	}
+	return c.CreateApplicationVersionFunc(params)
+}
+
+func (c *Client) CreateEnvironment(params *elasticbeanstalk.CreateEnvironmentParams) error {
+	c.record("CreateEnvironment", params)
+	if c.CreateEnvironmentFunc == nil {
+		return nil
+	}
+	return c.CreateEnvironmentFunc(params)
+}
+
+func (c *Client) DeleteApplicationVersion(params *elasticbeanstalk.DeleteApplicationVersionParams) error {
+	c.record("DeleteApplicationVersion", params)
+	if c.DeleteApplicationVersionFunc == nil {
+		return nil
+	}
+	return c.DeleteApplicationVersionFunc(params)
+}
+
+func (c *Client) DescribeApplicationVersions(params *elasticbeanstalk.DescribeApplicationVersionsParams) (*elasticbeanstalk.DescribeApplicationVersionsResult, error) {
+	c.record("DescribeApplicationVersions", params)
+	if c.DescribeApplicationVersionsFunc == nil {
+		return &elasticbeanstalk.DescribeApplicationVersionsResult{}, nil
+	}
+	return c.DescribeApplicationVersionsFunc(params)
+}
+
+func (c *Client) DescribeConfigurationSettings(params *elasticbeanstalk.DescribeConfigurationSettingsParams) (elasticbeanstalk.ConfigurationSettings, error) {
+	c.record("DescribeConfigurationSettings", params)
+	if c.DescribeConfigurationSettingsFunc == nil {
+		return nil, nil
+	}
+	return c.DescribeConfigurationSettingsFunc(params)
+}
+
+func (c *Client) DescribeEnvironmentHealth(params *elasticbeanstalk.DescribeEnvironmentHealthParams) (*elasticbeanstalk.EnvironmentHealth, error) {
+	c.record("DescribeEnvironmentHealth", params)
+	if c.DescribeEnvironmentHealthFunc == nil {
+		return &elasticbeanstalk.EnvironmentHealth{}, nil
+	}
+	return c.DescribeEnvironmentHealthFunc(params)
+}
+
+func (c *Client) DescribeEnvironments(params *elasticbeanstalk.DescribeEnvironmentsParams) ([]*elasticbeanstalk.EnvironmentDescription, error) {
+	c.record("DescribeEnvironments", params)
+	if c.DescribeEnvironmentsFunc == nil {
+		return nil, nil
+	}
+	return c.DescribeEnvironmentsFunc(params)
+}
+
+func (c *Client) DescribeEvents(params *elasticbeanstalk.DescribeEventsParams) (*elasticbeanstalk.DescribeEventsResult, error) {
+	c.record("DescribeEvents", params)
+	if c.DescribeEventsFunc == nil {
+		return &elasticbeanstalk.DescribeEventsResult{}, nil
+	}
+	return c.DescribeEventsFunc(params)
+}
+
+func (c *Client) RequestEnvironmentInfo(params *elasticbeanstalk.RequestEnvironmentInfoParams) error {
+	c.record("RequestEnvironmentInfo", params)
+	if c.RequestEnvironmentInfoFunc == nil {
+		return nil
+	}
+	return c.RequestEnvironmentInfoFunc(params)
+}
+
+func (c *Client) RetrieveEnvironmentInfo(params *elasticbeanstalk.RetrieveEnvironmentInfoParams) ([]*elasticbeanstalk.EnvironmentInfoDescription, error) {
+	c.record("RetrieveEnvironmentInfo", params)
+	if c.RetrieveEnvironmentInfoFunc == nil {
+		return nil, nil
+	}
+	return c.RetrieveEnvironmentInfoFunc(params)
+}
+
+func (c *Client) SwapEnvironmentCNAMEs(params *elasticbeanstalk.SwapEnvironmentCNAMEsParams) error {
+	c.record("SwapEnvironmentCNAMEs", params)
+	if c.SwapEnvironmentCNAMEsFunc == nil {
+		return nil
+	}
+	return c.SwapEnvironmentCNAMEsFunc(params)
+}
+
+func (c *Client) TerminateEnvironment(params *elasticbeanstalk.TerminateEnvironmentParams) error {
+	c.record("TerminateEnvironment", params)
+	if c.TerminateEnvironmentFunc == nil {
+		return nil
+	}
+	return c.TerminateEnvironmentFunc(params)
+}
+
+func (c *Client) UpdateEnvironment(params *elasticbeanstalk.UpdateEnvironmentParams) error {
+	c.record("UpdateEnvironment", params)
+	if c.UpdateEnvironmentFunc == nil {
+		return nil
+	}
+	return c.UpdateEnvironmentFunc(params)
+}
@@ -0,0 +1,55 @@
+package ebmock
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/sqs/go-elasticbeanstalk/elasticbeanstalk"
+)
+
+func TestClient(t *testing.T) {
+	wantErr := errors.New("x")
+	c := &Client{
+		UpdateEnvironmentFunc: func(params *elasticbeanstalk.UpdateEnvironmentParams) error {
+			return wantErr
+		},
+	}
+	var api elasticbeanstalk.API = c
+
+	if _, err := api.DescribeEnvironments(&elasticbeanstalk.DescribeEnvironmentsParams{ApplicationName: "app"}); err != nil {
+		t.Errorf("DescribeEnvironments returned error: %v", err)
+	}
+	p := &elasticbeanstalk.UpdateEnvironmentParams{EnvironmentName: "env"}
+	if err := api.UpdateEnvironment(p); err != wantErr {
+		t.Errorf("UpdateEnvironment returned error %v, want %v", err, wantErr)
+	}
+
+	want := []Call{{Operation: "UpdateEnvironment", Params: p}}
+	if got := c.CallsTo("UpdateEnvironment"); !reflect.DeepEqual(got, want) {
+		t.Errorf("got calls %+v, want %+v", got, want)
+	}
+	if got := len(c.Calls()); got != 2 {
+		t.Errorf("got %d calls, want 2", got)
+	}
+	c.Reset()
+	if got := len(c.Calls()); got != 0 {
+		t.Errorf("got %d calls after Reset, want 0", got)
+	}
+}
+
+func TestClient_emptyResults(t *testing.T) {
+	c := &Client{}
+	if res, err := c.CheckDNSAvailability(&elasticbeanstalk.CheckDNSAvailabilityParams{}); res == nil || err != nil {
+		t.Errorf("CheckDNSAvailability returned %v, %v; want an empty result", res, err)
+	}
+	if res, err := c.DescribeApplicationVersions(&elasticbeanstalk.DescribeApplicationVersionsParams{}); res == nil || err != nil {
+		t.Errorf("DescribeApplicationVersions returned %v, %v; want an empty result", res, err)
+	}
+	if res, err := c.DescribeEnvironmentHealth(&elasticbeanstalk.DescribeEnvironmentHealthParams{}); res == nil || err != nil {
+		t.Errorf("DescribeEnvironmentHealth returned %v, %v; want an empty result", res, err)
+	}
+	if res, err := c.DescribeEvents(&elasticbeanstalk.DescribeEventsParams{}); res == nil || err != nil {
+		t.Errorf("DescribeEvents returned %v, %v; want an empty result", res, err)
+	}
+}
@@ -0,0 +1,1033 @@
+// Package ebtest provides an in-memory fake of the AWS Elastic Beanstalk API
+// for use in tests of code that uses the elasticbeanstalk package.
+//
+// A Server keeps state for applications, application versions, environments,
+// their configuration option settings, and events. Mutating calls put
+// environments into a transitional status (such as "Launching", "Updating", or
+// "Terminating"), and each subsequent DescribeEnvironments call moves them
+// closer to "Ready" (or "Terminated"), so callers that poll an environment can
+// be tested without waiting on real infrastructure.
+package ebtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sqs/go-elasticbeanstalk/elasticbeanstalk"
+)
+
+// Server is a fake Elastic Beanstalk API server backed by an
+// httptest.Server.
+type Server struct {
+	// URL is the base URL of the fake server, of the form
+	// http://ipaddr:port with no trailing slash.
+	URL string
+
+	// TransitionPolls is the number of DescribeEnvironments calls an
+	// environment remains in a transitional status before it advances to the
+	// next status. It defaults to 1.
+	TransitionPolls int
+
+	srv *httptest.Server
+
+	mu       sync.Mutex
+	apps     map[string]*application
+	envs     map[string]*environment // keyed on environment name
+	events   []elasticbeanstalk.EventDescription
+	faults   []*Fault
+	requests map[string]int
+	nextID   int
+}
+
+type application struct {
+	name     string
+	versions map[string]*elasticbeanstalk.ApplicationVersionDescription
+}
+
+type environment struct {
+	desc     elasticbeanstalk.EnvironmentDescription
+	settings elasticbeanstalk.ConfigurationOptionSettings
+
+	// next is the status that the environment will advance to after
+	// pollsLeft more DescribeEnvironments calls.
+	next      string
+	pollsLeft int
+
+	// causes are the reasons for the environment's health status, returned
+	// by DescribeEnvironmentHealth.
+	causes []string
+
+	// logs holds the logs of each instance, keyed on instance ID, and
+	// infoRequested holds the time that each type of info (such as "tail")
+	// was last requested with RequestEnvironmentInfo.
+	logs          map[string]*instanceLogs
+	infoRequested map[string]time.Time
+}
+
+type instanceLogs struct {
+	tail   string
+	bundle []byte
+}
+
+// A Fault makes the fake server fail matching requests instead of serving
+// them.
+type Fault struct {
+	// Operation is the API operation to fail (such as
+	// "DescribeEnvironments"). If empty, all operations fail.
+	Operation string
+
+	// StatusCode is the HTTP status code of the error response.
+	StatusCode int
+
+	// Code and Message are the AWS error code and message in the error
+	// response body.
+	Code    string
+	Message string
+
+	// Times is the number of requests to fail. If zero, all matching
+	// requests fail until the fault is removed with ClearFaults.
+	Times int
+}
+
+// NewServer starts and returns a new fake server. The caller should call
+// Close when finished, to shut it down.
+func NewServer() *Server {
+	s := &Server{
+		TransitionPolls: 1,
+		apps:            map[string]*application{},
+		envs:            map[string]*environment{},
+		requests:        map[string]int{},
+	}
+	s.srv = httptest.NewServer(s)
+	s.URL = s.srv.URL
+	return s
+}
+
+// Close shuts down the server.
+func (s *Server) Close() {
+	s.srv.Close()
+}
+
+// NewClient returns an elasticbeanstalk.Client that is configured to talk to
+// the fake server.
+func (s *Server) NewClient() *elasticbeanstalk.Client {
+	c := elasticbeanstalk.NewClient(s.srv.Client())
+	c.BaseURL, _ = url.Parse(s.URL)
+	return c
+}
+
+// AddApplication creates an application named name, if it doesn't already
+// exist.
+func (s *Server) AddApplication(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.addApplication(name)
+}
+
+func (s *Server) addApplication(name string) *application {
+	if app, present := s.apps[name]; present {
+		return app
+	}
+	app := &application{name: name, versions: map[string]*elasticbeanstalk.ApplicationVersionDescription{}}
+	s.apps[name] = app
+	return app
+}
+
+// AddEnvironment creates an environment from env, creating its application
+// if needed. Unset fields are filled in with defaults. If env.Status is
+// empty, the environment starts out "Launching" and becomes "Ready" after
+// TransitionPolls DescribeEnvironments calls.
+func (s *Server) AddEnvironment(env elasticbeanstalk.EnvironmentDescription, settings elasticbeanstalk.ConfigurationOptionSettings) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.addEnvironment(env, settings)
+}
+
+func (s *Server) addEnvironment(env elasticbeanstalk.EnvironmentDescription, settings elasticbeanstalk.ConfigurationOptionSettings) {
+	s.addApplication(env.ApplicationName)
+	now := elasticbeanstalk.Time{Time: time.Now().UTC()}
+	if env.EnvironmentId == "" {
+		env.EnvironmentId = s.newID("e-")
+	}
+	if env.CNAME == "" {
+		env.CNAME = env.EnvironmentName + ".elasticbeanstalk.com"
+	}
+	if env.DateCreated.IsZero() {
+		env.DateCreated = now
+	}
+	if env.DateUpdated.IsZero() {
+		env.DateUpdated = now
+	}
+	if env.Health == "" {
+		env.Health = "Grey"
+	}
+	e := &environment{desc: env, settings: append(elasticbeanstalk.ConfigurationOptionSettings(nil), settings...)}
+	if e.desc.Status == "" {
+		e.desc.Status = "Launching"
+		e.next = "Ready"
+		e.pollsLeft = s.transitionPolls()
+		s.addEvent(env.ApplicationName, env.EnvironmentName, env.VersionLabel, "INFO", "createEnvironment is starting.")
+	}
+	s.envs[env.EnvironmentName] = e
+}
+
+// Environment returns a copy of the current description of the named
+// environment.
+func (s *Server) Environment(name string) (elasticbeanstalk.EnvironmentDescription, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, present := s.envs[name]
+	if !present {
+		return elasticbeanstalk.EnvironmentDescription{}, false
+	}
+	return e.desc, true
+}
+
+// SetStatus sets the status and health of the named environment, cancelling
+// any pending transition.
+func (s *Server) SetStatus(envName, status, health string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e, present := s.envs[envName]; present {
+		e.desc.Status = status
+		e.desc.Health = health
+		e.next, e.pollsLeft = "", 0
+	}
+}
+
+// SetHealth sets the enhanced health status (such as "Ok" or "Severe") of
+// the named environment, and the causes that DescribeEnvironmentHealth
+// reports for it.
+func (s *Server) SetHealth(envName, healthStatus string, causes ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e, present := s.envs[envName]; present {
+		e.desc.HealthStatus = healthStatus
+		e.causes = causes
+	}
+}
+
+// SetInstanceLogs sets the logs of an instance of the named environment,
+// which are returned (after RequestEnvironmentInfo is called) by
+// RetrieveEnvironmentInfo: tail for the "tail" info type, and bundle (a zip
+// archive) for the "bundle" info type.
+func (s *Server) SetInstanceLogs(envName, instanceID, tail string, bundle []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e, present := s.envs[envName]; present {
+		if e.logs == nil {
+			e.logs = map[string]*instanceLogs{}
+		}
+		e.logs[instanceID] = &instanceLogs{tail: tail, bundle: bundle}
+	}
+}
+
+// AddEvent records an event, as if it had been emitted by the named
+// environment (or by the application, if envName is empty).
+func (s *Server) AddEvent(appName, envName, severity, message string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	label := ""
+	if e, present := s.envs[envName]; present {
+		label = e.desc.VersionLabel
+	}
+	s.addEvent(appName, envName, label, severity, message)
+}
+
+// OptionSettings returns a copy of the option settings of the named
+// environment.
+func (s *Server) OptionSettings(envName string) elasticbeanstalk.ConfigurationOptionSettings {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e, present := s.envs[envName]; present {
+		return append(elasticbeanstalk.ConfigurationOptionSettings(nil), e.settings...)
+	}
+	return nil
+}
+
+// AddApplicationVersion creates an application version from v, creating its
+// application if needed. If v.DateCreated is zero, it is set to the current
+// time.
+func (s *Server) AddApplicationVersion(v elasticbeanstalk.ApplicationVersionDescription) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if v.DateCreated.IsZero() {
+		v.DateCreated = elasticbeanstalk.Time{Time: time.Now().UTC()}
+	}
+	if v.DateUpdated.IsZero() {
+		v.DateUpdated = v.DateCreated
+	}
+	if v.Status == "" {
+		v.Status = "PROCESSED"
+	}
+	s.addApplication(v.ApplicationName).versions[v.VersionLabel] = &v
+}
+
+// ApplicationVersions returns the versions of the named application, newest
+// first.
+func (s *Server) ApplicationVersions(appName string) []elasticbeanstalk.ApplicationVersionDescription {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	app, present := s.apps[appName]
+	if !present {
+		return nil
+	}
+	return app.sortedVersions()
+}
+
+func (app *application) sortedVersions() []elasticbeanstalk.ApplicationVersionDescription {
+	vs := make([]elasticbeanstalk.ApplicationVersionDescription, 0, len(app.versions))
+	for _, v := range app.versions {
+		vs = append(vs, *v)
+	}
+	sort.Slice(vs, func(i, j int) bool { return vs[i].DateCreated.After(vs[j].DateCreated.Time) })
+	return vs
+}
+
+// Events returns all events recorded so far, newest first.
+func (s *Server) Events() []elasticbeanstalk.EventDescription {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	evs := make([]elasticbeanstalk.EventDescription, len(s.events))
+	for i, ev := range s.events {
+		evs[len(evs)-1-i] = ev
+	}
+	return evs
+}
+
+// Advance moves every environment that is in a transitional status to its
+// next status immediately, as if enough DescribeEnvironments calls had been
+// made.
+func (s *Server) Advance() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, e := range s.envs {
+		if e.next != "" {
+			e.pollsLeft = 0
+			s.advance(e)
+		}
+	}
+}
+
+// InjectFault makes the server fail requests matching f.
+func (s *Server) InjectFault(f Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = append(s.faults, &f)
+}
+
+// Throttle makes the next n requests for operation fail with a throttling
+// error, as AWS does when a caller exceeds its request rate.
+func (s *Server) Throttle(operation string, n int) {
+	s.InjectFault(Fault{Operation: operation, StatusCode: http.StatusBadRequest, Code: "Throttling", Message: "Rate exceeded", Times: n})
+}
+
+// FailWith5xx makes the next n requests for operation fail with an internal
+// server error.
+func (s *Server) FailWith5xx(operation string, n int) {
+	s.InjectFault(Fault{Operation: operation, StatusCode: http.StatusInternalServerError, Code: "InternalFailure", Message: "An internal error occurred.", Times: n})
+}
+
+// ClearFaults removes all injected faults.
+func (s *Server) ClearFaults() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = nil
+}
+
+// Requests returns the number of requests received for operation,
+// including those that failed.
+func (s *Server) Requests(operation string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[operation]
+}
+
+func (s *Server) transitionPolls() int {
+	if s.TransitionPolls <= 0 {
+		return 1
+	}
+	return s.TransitionPolls
+}
+
+func (s *Server) newID(prefix string) string {
+	s.nextID++
+	return fmt.Sprintf("%s%08d", prefix, s.nextID)
+}
+
+func (s *Server) addEvent(app, env, label, severity, msg string) {
+	s.events = append(s.events, elasticbeanstalk.EventDescription{
+		ApplicationName: app,
+		EnvironmentName: env,
+		EventDate:       elasticbeanstalk.Time{Time: time.Now().UTC()},
+		Message:         msg,
+		Severity:        severity,
+		VersionLabel:    label,
+	})
+}
+
+// poll is called for each environment returned by DescribeEnvironments, and
+// advances it once it has been polled enough times.
+func (s *Server) poll(e *environment) {
+	if e.next == "" {
+		return
+	}
+	e.pollsLeft--
+	s.advance(e)
+}
+
+func (s *Server) advance(e *environment) {
+	if e.pollsLeft > 0 {
+		return
+	}
+	prev := e.desc.Status
+	e.desc.Status = e.next
+	e.next = ""
+	e.desc.DateUpdated = elasticbeanstalk.Time{Time: time.Now().UTC()}
+	switch e.desc.Status {
+	case "Ready":
+		e.desc.Health = "Green"
+		msg := "Environment update completed successfully."
+		if prev == "Launching" {
+			msg = "Successfully launched environment: " + e.desc.EnvironmentName
+		}
+		s.addEvent(e.desc.ApplicationName, e.desc.EnvironmentName, e.desc.VersionLabel, "INFO", msg)
+	case "Terminated":
+		e.desc.Health = "Grey"
+		s.addEvent(e.desc.ApplicationName, e.desc.EnvironmentName, e.desc.VersionLabel, "INFO", "terminateEnvironment completed successfully.")
+	}
+}
+
+// apiError is an error response returned by an operation handler.
+type apiError struct {
+	statusCode int
+	code, msg  string
+}
+
+func (e *apiError) Error() string { return e.code + ": " + e.msg }
+
+func invalidParam(format string, args ...interface{}) *apiError {
+	return &apiError{http.StatusBadRequest, "InvalidParameterValue", fmt.Sprintf(format, args...)}
+}
+
+type handlerFunc func(s *Server, params url.Values) (interface{}, error)
+
+var handlers = map[string]handlerFunc{
+	"CheckDNSAvailability":          (*Server).checkDNSAvailability,
+	"CreateApplicationVersion":      (*Server).createApplicationVersion,
+	"CreateEnvironment":             (*Server).createEnvironment,
+	"DeleteApplicationVersion":      (*Server).deleteApplicationVersion,
+	"DescribeApplicationVersions":   (*Server).describeApplicationVersions,
+	"DescribeConfigurationSettings": (*Server).describeConfigurationSettings,
+	"DescribeEnvironments":          (*Server).describeEnvironments,
+	"DescribeEnvironmentHealth":     (*Server).describeEnvironmentHealth,
+	"DescribeEvents":                (*Server).describeEvents,
+	"RequestEnvironmentInfo":        (*Server).requestEnvironmentInfo,
+	"RetrieveEnvironmentInfo":       (*Server).retrieveEnvironmentInfo,
+	"SwapEnvironmentCNAMEs":         (*Server).swapEnvironmentCNAMEs,
+	"TerminateEnvironment":          (*Server).terminateEnvironment,
+	"UpdateEnvironment":             (*Server).updateEnvironment,
+}
+
+// logsPath is the path prefix of the URLs of instance logs returned by
+// RetrieveEnvironmentInfo.
+const logsPath = "/logs/"
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if strings.HasPrefix(r.URL.Path, logsPath) {
+		s.serveLogs(w, r)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	op := r.Form.Get("Operation")
+	if op == "" {
+		op = r.Form.Get("Action")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.requests[op]++
+	requestID := s.newID("req-")
+	w.Header().Set("X-Amzn-Requestid", requestID)
+
+	if f := s.fault(op); f != nil {
+		writeError(w, requestID, &apiError{f.StatusCode, f.Code, f.Message})
+		return
+	}
+
+	h, present := handlers[op]
+	if !present {
+		writeError(w, requestID, &apiError{http.StatusBadRequest, "InvalidAction", fmt.Sprintf("Could not find operation %q.", op)})
+		return
+	}
+	result, err := h(s, r.Form)
+	if err != nil {
+		ae, ok := err.(*apiError)
+		if !ok {
+			ae = &apiError{http.StatusInternalServerError, "InternalFailure", err.Error()}
+		}
+		writeError(w, requestID, ae)
+		return
+	}
+
+	resp := map[string]interface{}{
+		op + "Response": map[string]interface{}{
+			op + "Result":      result,
+			"ResponseMetadata": map[string]string{"RequestId": requestID},
+		},
+	}
+	w.Header().Set("content-type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// fault returns the first fault matching op, if any, and consumes one use
+// of it.
+func (s *Server) fault(op string) *Fault {
+	for i, f := range s.faults {
+		if f.Operation != "" && f.Operation != op {
+			continue
+		}
+		if f.Times > 0 {
+			f.Times--
+			if f.Times == 0 {
+				s.faults = append(s.faults[:i:i], s.faults[i+1:]...)
+			}
+		}
+		return f
+	}
+	return nil
+}
+
+func writeError(w http.ResponseWriter, requestID string, e *apiError) {
+	typ := "Sender"
+	if e.statusCode >= 500 {
+		typ = "Receiver"
+	}
+	w.Header().Set("content-type", "application/json")
+	w.WriteHeader(e.statusCode)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"Error":     map[string]string{"Code": e.code, "Message": e.msg, "Type": typ},
+		"RequestId": requestID,
+	})
+}
+
+// members returns the values of the list parameter prefix (such as
+// "EnvironmentNames.member"), ordered by index.
+func members(params url.Values, prefix string) []string {
+	type member struct {
+		i int
+		v string
+	}
+	var ms []member
+	for k, vs := range params {
+		if !strings.HasPrefix(k, prefix+".") {
+			continue
+		}
+		i, err := strconv.Atoi(strings.TrimPrefix(k, prefix+"."))
+		if err != nil {
+			continue
+		}
+		ms = append(ms, member{i, vs[0]})
+	}
+	sort.Slice(ms, func(i, j int) bool { return ms[i].i < ms[j].i })
+	vs := make([]string, len(ms))
+	for i, m := range ms {
+		vs[i] = m.v
+	}
+	return vs
+}
+
+// optionSettings returns the option settings encoded in params under
+// prefix (such as "OptionSettings.member").
+func optionSettings(params url.Values, prefix string) elasticbeanstalk.ConfigurationOptionSettings {
+	var opts elasticbeanstalk.ConfigurationOptionSettings
+	for i := 1; ; i++ {
+		kp := fmt.Sprintf("%s.%d.", prefix, i)
+		if _, present := params[kp+"Namespace"]; !present {
+			break
+		}
+		opts = append(opts, elasticbeanstalk.ConfigurationOptionSetting{
+			Namespace:    params.Get(kp + "Namespace"),
+			OptionName:   params.Get(kp + "OptionName"),
+			ResourceName: params.Get(kp + "ResourceName"),
+			Value:        params.Get(kp + "Value"),
+		})
+	}
+	return opts
+}
+
+func (s *Server) lookupEnvironment(params url.Values) (*environment, error) {
+	name := params.Get("EnvironmentName")
+	if id := params.Get("EnvironmentId"); name == "" && id != "" {
+		for _, e := range s.envs {
+			if e.desc.EnvironmentId == id {
+				return e, nil
+			}
+		}
+		return nil, invalidParam("No Environment found for EnvironmentId = '%s'.", id)
+	}
+	e, present := s.envs[name]
+	if !present {
+		return nil, invalidParam("No Environment found for EnvironmentName = '%s'.", name)
+	}
+	return e, nil
+}
+
+// cnameDomain is the domain of the CNAMEs of environments created with
+// CreateEnvironment.
+const cnameDomain = ".elasticbeanstalk.com"
+
+// cnameInUse reports whether an environment that isn't terminated has a
+// CNAME with the given prefix.
+func (s *Server) cnameInUse(prefix string) bool {
+	for _, e := range s.envs {
+		if e.desc.Status == "Terminated" {
+			continue
+		}
+		if label, _, _ := strings.Cut(e.desc.CNAME, "."); strings.EqualFold(label, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Server) checkDNSAvailability(params url.Values) (interface{}, error) {
+	prefix := params.Get("CNAMEPrefix")
+	if prefix == "" {
+		return nil, invalidParam("CNAMEPrefix is required.")
+	}
+	return &elasticbeanstalk.CheckDNSAvailabilityResult{
+		Available:           !s.cnameInUse(prefix),
+		FullyQualifiedCNAME: strings.ToLower(prefix) + cnameDomain,
+	}, nil
+}
+
+func (s *Server) createEnvironment(params url.Values) (interface{}, error) {
+	appName, envName := params.Get("ApplicationName"), params.Get("EnvironmentName")
+	app, present := s.apps[appName]
+	if !present {
+		return nil, invalidParam("No Application named '%s' found.", appName)
+	}
+	if envName == "" {
+		return nil, invalidParam("EnvironmentName is required.")
+	}
+	if e, present := s.envs[envName]; present && e.desc.Status != "Terminated" {
+		return nil, invalidParam("Environment %s already exists.", envName)
+	}
+	stack, template := params.Get("SolutionStackName"), params.Get("TemplateName")
+	if (stack == "") == (template == "") {
+		return nil, invalidParam("Exactly one of SolutionStackName and TemplateName must be specified.")
+	}
+	label := params.Get("VersionLabel")
+	if _, present := app.versions[label]; label != "" && !present {
+		return nil, invalidParam("No Application Version named '%s' found.", label)
+	}
+	prefix := params.Get("CNAMEPrefix")
+	if prefix == "" {
+		prefix = envName
+	}
+	if s.cnameInUse(prefix) {
+		return nil, invalidParam("DNS name (%s%s) is not available.", prefix, cnameDomain)
+	}
+
+	s.addEnvironment(elasticbeanstalk.EnvironmentDescription{
+		ApplicationName:   appName,
+		EnvironmentName:   envName,
+		CNAME:             strings.ToLower(prefix) + cnameDomain,
+		Description:       params.Get("Description"),
+		SolutionStackName: stack,
+		TemplateName:      template,
+		VersionLabel:      label,
+	}, optionSettings(params, "OptionSettings.member"))
+	desc := s.envs[envName].desc
+	return &desc, nil
+}
+
+func (s *Server) createApplicationVersion(params url.Values) (interface{}, error) {
+	appName, label := params.Get("ApplicationName"), params.Get("VersionLabel")
+	app, present := s.apps[appName]
+	if !present {
+		return nil, invalidParam("No Application named '%s' found.", appName)
+	}
+	if label == "" {
+		return nil, invalidParam("VersionLabel is required.")
+	}
+	if _, exists := app.versions[label]; exists {
+		return nil, invalidParam("Application Version %s already exists.", label)
+	}
+	now := elasticbeanstalk.Time{Time: time.Now().UTC()}
+	v := &elasticbeanstalk.ApplicationVersionDescription{
+		ApplicationName: appName,
+		VersionLabel:    label,
+		Description:     params.Get("Description"),
+		SourceBundle: elasticbeanstalk.S3Location{
+			S3Bucket: params.Get("SourceBundle.S3Bucket"),
+			S3Key:    params.Get("SourceBundle.S3Key"),
+		},
+		DateCreated: now,
+		DateUpdated: now,
+		Status:      "PROCESSED",
+	}
+	app.versions[label] = v
+	s.addEvent(appName, "", label, "INFO", fmt.Sprintf("Created new Application Version (%s)", label))
+	return struct{}{}, nil
+}
+
+func (s *Server) deleteApplicationVersion(params url.Values) (interface{}, error) {
+	appName, label := params.Get("ApplicationName"), params.Get("VersionLabel")
+	app, present := s.apps[appName]
+	if !present {
+		return nil, invalidParam("No Application named '%s' found.", appName)
+	}
+	if _, present := app.versions[label]; !present {
+		return nil, invalidParam("No Application Version named '%s' found.", label)
+	}
+	var names []string
+	for _, e := range s.envs {
+		if e.desc.ApplicationName == appName && e.desc.VersionLabel == label {
+			names = append(names, e.desc.EnvironmentName)
+		}
+	}
+	if len(names) > 0 {
+		sort.Strings(names)
+		return nil, invalidParam("Unable to delete application version %s because it is being used by the following environments: %s", label, strings.Join(names, ", "))
+	}
+	delete(app.versions, label)
+	return struct{}{}, nil
+}
+
+func (s *Server) describeApplicationVersions(params url.Values) (interface{}, error) {
+	appName := params.Get("ApplicationName")
+	labels := map[string]bool{}
+	for _, label := range members(params, "VersionLabels.member") {
+		labels[label] = true
+	}
+	appNames := make([]string, 0, len(s.apps))
+	for name := range s.apps {
+		if appName == "" || name == appName {
+			appNames = append(appNames, name)
+		}
+	}
+	sort.Strings(appNames)
+	vs := []elasticbeanstalk.ApplicationVersionDescription{}
+	for _, name := range appNames {
+		for _, v := range s.apps[name].sortedVersions() {
+			if len(labels) == 0 || labels[v.VersionLabel] {
+				vs = append(vs, v)
+			}
+		}
+	}
+
+	// The next token is the offset of the next page.
+	var offset int
+	if token := params.Get("NextToken"); token != "" {
+		var err error
+		if offset, err = strconv.Atoi(token); err != nil || offset > len(vs) {
+			return nil, invalidParam("Invalid NextToken: %s", token)
+		}
+	}
+	vs = vs[offset:]
+	result := map[string]interface{}{}
+	if max, _ := strconv.Atoi(params.Get("MaxRecords")); max > 0 && len(vs) > max {
+		vs = vs[:max]
+		result["NextToken"] = strconv.Itoa(offset + max)
+	}
+	result["ApplicationVersions"] = vs
+	return result, nil
+}
+
+func (s *Server) describeEnvironments(params url.Values) (interface{}, error) {
+	appName := params.Get("ApplicationName")
+	names := map[string]bool{}
+	for _, name := range members(params, "EnvironmentNames.member") {
+		names[name] = true
+	}
+
+	envs := []*elasticbeanstalk.EnvironmentDescription{}
+	for _, e := range s.envs {
+		if appName != "" && e.desc.ApplicationName != appName {
+			continue
+		}
+		if len(names) > 0 && !names[e.desc.EnvironmentName] {
+			continue
+		}
+		s.poll(e)
+		desc := e.desc
+		envs = append(envs, &desc)
+	}
+	sort.Slice(envs, func(i, j int) bool { return envs[i].EnvironmentName < envs[j].EnvironmentName })
+	return map[string]interface{}{"Environments": envs}, nil
+}
+
+func (s *Server) describeConfigurationSettings(params url.Values) (interface{}, error) {
+	e, err := s.lookupEnvironment(params)
+	if err != nil {
+		return nil, err
+	}
+	if appName := params.Get("ApplicationName"); appName != e.desc.ApplicationName {
+		return nil, invalidParam("No Environment found for EnvironmentName = '%s'.", e.desc.EnvironmentName)
+	}
+	cs := elasticbeanstalk.ConfigurationSettings{
+		{
+			ApplicationName:   e.desc.ApplicationName,
+			DateCreated:       e.desc.DateCreated,
+			DateUpdated:       e.desc.DateUpdated,
+			DeploymentStatus:  "deployed",
+			EnvironmentName:   e.desc.EnvironmentName,
+			OptionSettings:    append(elasticbeanstalk.ConfigurationOptionSettings{}, e.settings...),
+			SolutionStackName: e.desc.SolutionStackName,
+		},
+	}
+	return map[string]interface{}{"ConfigurationSettings": cs}, nil
+}
+
+func (s *Server) describeEvents(params url.Values) (interface{}, error) {
+	appName, envName := params.Get("ApplicationName"), params.Get("EnvironmentName")
+	label, severity := params.Get("VersionLabel"), params.Get("Severity")
+	var startTime, endTime time.Time
+	for name, t := range map[string]*time.Time{"StartTime": &startTime, "EndTime": &endTime} {
+		if v := params.Get(name); v != "" {
+			var err error
+			if *t, err = time.Parse(time.RFC3339, v); err != nil {
+				return nil, invalidParam("Invalid %s: %s", name, v)
+			}
+		}
+	}
+	evs := []elasticbeanstalk.EventDescription{}
+	for i := len(s.events) - 1; i >= 0; i-- {
+		ev := s.events[i]
+		if (appName != "" && ev.ApplicationName != appName) ||
+			(envName != "" && ev.EnvironmentName != envName) ||
+			(label != "" && ev.VersionLabel != label) ||
+			(severity != "" && severityRank[ev.Severity] < severityRank[severity]) ||
+			(!startTime.IsZero() && ev.EventDate.Before(startTime)) ||
+			(!endTime.IsZero() && !ev.EventDate.Before(endTime)) {
+			continue
+		}
+		evs = append(evs, ev)
+	}
+
+	// The next token is the offset of the next page.
+	var offset int
+	if token := params.Get("NextToken"); token != "" {
+		var err error
+		if offset, err = strconv.Atoi(token); err != nil || offset > len(evs) {
+			return nil, invalidParam("Invalid NextToken: %s", token)
+		}
+	}
+	evs = evs[offset:]
+	result := map[string]interface{}{}
+	if max, _ := strconv.Atoi(params.Get("MaxRecords")); max > 0 && len(evs) > max {
+		evs = evs[:max]
+		result["NextToken"] = strconv.Itoa(offset + max)
+	}
+	result["Events"] = evs
+	return result, nil
+}
+
+// healthColors maps enhanced health statuses to their colors.
+var healthColors = map[string]string{
+	"Ok":       "Green",
+	"Info":     "Green",
+	"Warning":  "Yellow",
+	"Degraded": "Red",
+	"Severe":   "Red",
+	"Pending":  "Grey",
+	"Unknown":  "Grey",
+	"NoData":   "Grey",
+}
+
+func (s *Server) describeEnvironmentHealth(params url.Values) (interface{}, error) {
+	e, err := s.lookupEnvironment(params)
+	if err != nil {
+		return nil, err
+	}
+	h := &elasticbeanstalk.EnvironmentHealth{
+		EnvironmentName: e.desc.EnvironmentName,
+		HealthStatus:    e.desc.HealthStatus,
+		Color:           e.desc.Health,
+		Status:          e.desc.Status,
+		Causes:          append([]string{}, e.causes...),
+		RefreshedAt:     elasticbeanstalk.Time{Time: time.Now().UTC()},
+	}
+	if h.HealthStatus == "" {
+		h.HealthStatus = map[string]string{"Green": "Ok", "Yellow": "Warning", "Red": "Degraded"}[e.desc.Health]
+		if h.HealthStatus == "" {
+			h.HealthStatus = "Unknown"
+		}
+	} else if c, present := healthColors[h.HealthStatus]; present {
+		h.Color = c
+	}
+	return h, nil
+}
+
+var severityRank = map[string]int{"TRACE": 0, "DEBUG": 1, "INFO": 2, "WARN": 3, "ERROR": 4, "FATAL": 5}
+
+func infoType(params url.Values) (string, error) {
+	switch t := params.Get("InfoType"); t {
+	case elasticbeanstalk.InfoTail, elasticbeanstalk.InfoBundle:
+		return t, nil
+	default:
+		return "", invalidParam("Invalid InfoType: %s", t)
+	}
+}
+
+func (s *Server) requestEnvironmentInfo(params url.Values) (interface{}, error) {
+	e, err := s.lookupEnvironment(params)
+	if err != nil {
+		return nil, err
+	}
+	typ, err := infoType(params)
+	if err != nil {
+		return nil, err
+	}
+	if e.infoRequested == nil {
+		e.infoRequested = map[string]time.Time{}
+	}
+	e.infoRequested[typ] = time.Now().UTC()
+	return struct{}{}, nil
+}
+
+func (s *Server) retrieveEnvironmentInfo(params url.Values) (interface{}, error) {
+	e, err := s.lookupEnvironment(params)
+	if err != nil {
+		return nil, err
+	}
+	typ, err := infoType(params)
+	if err != nil {
+		return nil, err
+	}
+	info := []*elasticbeanstalk.EnvironmentInfoDescription{}
+	if requested, present := e.infoRequested[typ]; present {
+		ids := make([]string, 0, len(e.logs))
+		for id := range e.logs {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		for _, id := range ids {
+			info = append(info, &elasticbeanstalk.EnvironmentInfoDescription{
+				Ec2InstanceId:   id,
+				InfoType:        typ,
+				Message:         s.URL + logsPath + url.PathEscape(e.desc.EnvironmentName) + "/" + id + "/" + typ,
+				SampleTimestamp: elasticbeanstalk.Time{Time: requested},
+			})
+		}
+	}
+	return map[string]interface{}{"EnvironmentInfo": info}, nil
+}
+
+// serveLogs serves the logs at the URLs returned by RetrieveEnvironmentInfo.
+func (s *Server) serveLogs(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, logsPath), "/")
+	if len(parts) != 3 {
+		http.NotFound(w, r)
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, present := s.envs[parts[0]]
+	if !present || e.logs[parts[1]] == nil {
+		http.NotFound(w, r)
+		return
+	}
+	logs := e.logs[parts[1]]
+	switch parts[2] {
+	case elasticbeanstalk.InfoTail:
+		w.Header().Set("content-type", "text/plain")
+		io.WriteString(w, logs.tail)
+	case elasticbeanstalk.InfoBundle:
+		w.Header().Set("content-type", "application/zip")
+		w.Write(logs.bundle)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *Server) swapEnvironmentCNAMEs(params url.Values) (interface{}, error) {
+	var envs [2]*environment
+	for i, role := range []string{"Source", "Destination"} {
+		name := params.Get(role + "EnvironmentName")
+		e, present := s.envs[name]
+		if !present {
+			return nil, invalidParam("No Environment found for %sEnvironmentName = '%s'.", role, name)
+		}
+		if e.desc.Status != "Ready" {
+			return nil, invalidParam("Environment named %s is in an invalid state for this operation. Must be Ready.", name)
+		}
+		envs[i] = e
+	}
+	src, dst := envs[0], envs[1]
+	if src == dst {
+		return nil, invalidParam("Cannot swap CNAMEs of an environment with itself.")
+	}
+	src.desc.CNAME, dst.desc.CNAME = dst.desc.CNAME, src.desc.CNAME
+	for _, e := range envs {
+		e.desc.Status = "Updating"
+		e.next = "Ready"
+		e.pollsLeft = s.transitionPolls()
+		e.desc.DateUpdated = elasticbeanstalk.Time{Time: time.Now().UTC()}
+	}
+	s.addEvent(src.desc.ApplicationName, src.desc.EnvironmentName, src.desc.VersionLabel, "INFO", fmt.Sprintf("Completed swapping CNAMEs for environments '%s' and '%s'.", src.desc.EnvironmentName, dst.desc.EnvironmentName))
+	return struct{}{}, nil
+}
+
+func (s *Server) updateEnvironment(params url.Values) (interface{}, error) {
+	e, err := s.lookupEnvironment(params)
+	if err != nil {
+		return nil, err
+	}
+	if e.desc.Status != "Ready" {
+		return nil, invalidParam("Environment named %s is in an invalid state for this operation. Must be Ready.", e.desc.EnvironmentName)
+	}
+	if label := params.Get("VersionLabel"); label != "" {
+		if _, present := s.apps[e.desc.ApplicationName].versions[label]; !present {
+			return nil, invalidParam("No Application Version named '%s' found.", label)
+		}
+		e.desc.VersionLabel = label
+	}
+	for _, o := range optionSettings(params, "OptionsToRemove.member") {
+		e.settings.Remove(o.Key())
+	}
+	for _, o := range optionSettings(params, "OptionSettings.member") {
+		e.settings.Set(o.Key(), o.Value)
+	}
+
+	e.desc.Status = "Updating"
+	e.next = "Ready"
+	e.pollsLeft = s.transitionPolls()
+	e.desc.DateUpdated = elasticbeanstalk.Time{Time: time.Now().UTC()}
+	s.addEvent(e.desc.ApplicationName, e.desc.EnvironmentName, e.desc.VersionLabel, "INFO", "Environment update is starting.")
+	desc := e.desc
+	return &desc, nil
+}
+
+func (s *Server) terminateEnvironment(params url.Values) (interface{}, error) {
+	e, err := s.lookupEnvironment(params)
+	if err != nil {
+		return nil, err
+	}
+	if e.desc.Status != "Ready" {
+		return nil, invalidParam("Environment named %s is in an invalid state for this operation. Must be Ready.", e.desc.EnvironmentName)
+	}
+	e.desc.Status = "Terminating"
+	e.next = "Terminated"
+	e.pollsLeft = s.transitionPolls()
+	e.desc.DateUpdated = elasticbeanstalk.Time{Time: time.Now().UTC()}
+	s.addEvent(e.desc.ApplicationName, e.desc.EnvironmentName, e.desc.VersionLabel, "INFO", "terminateEnvironment is starting.")
+	desc := e.desc
+	return &desc, nil
+}
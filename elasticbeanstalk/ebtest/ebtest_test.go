@@ -0,0 +1,343 @@
+package ebtest
+
+import (
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sqs/go-elasticbeanstalk/elasticbeanstalk"
+)
+
+func TestServer_statusTransitions(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	c := s.NewClient()
+
+	s.AddEnvironment(elasticbeanstalk.EnvironmentDescription{ApplicationName: "app", EnvironmentName: "env"}, nil)
+
+	wantStatus := func(want string) {
+		envs, err := c.DescribeEnvironments(&elasticbeanstalk.DescribeEnvironmentsParams{ApplicationName: "app", EnvironmentName: "env"})
+		if err != nil {
+			t.Fatalf("DescribeEnvironments returned error: %v", err)
+		}
+		if len(envs) != 1 {
+			t.Fatalf("got %d environments, want 1", len(envs))
+		}
+		if envs[0].Status != want {
+			t.Errorf("got status %q, want %q", envs[0].Status, want)
+		}
+	}
+
+	// Updates are rejected until the environment is Ready.
+	if err := c.UpdateEnvironment(&elasticbeanstalk.UpdateEnvironmentParams{EnvironmentName: "env"}); err == nil {
+		t.Error("UpdateEnvironment on a launching environment succeeded, want error")
+	}
+	wantStatus("Ready")
+
+	if err := c.CreateApplicationVersion(&elasticbeanstalk.CreateApplicationVersionParams{ApplicationName: "app", VersionLabel: "v1"}); err != nil {
+		t.Fatalf("CreateApplicationVersion returned error: %v", err)
+	}
+	p := &elasticbeanstalk.UpdateEnvironmentParams{EnvironmentName: "env", VersionLabel: "v1"}
+	p.AddEnv("K", "V")
+	if err := c.UpdateEnvironment(p); err != nil {
+		t.Fatalf("UpdateEnvironment returned error: %v", err)
+	}
+	env, _ := s.Environment("env")
+	if env.Status != "Updating" {
+		t.Errorf("got status %q after update, want %q", env.Status, "Updating")
+	}
+	wantStatus("Ready")
+
+	env, _ = s.Environment("env")
+	if env.VersionLabel != "v1" {
+		t.Errorf("got version label %q, want %q", env.VersionLabel, "v1")
+	}
+	if got, want := s.OptionSettings("env").Environ(), map[string]string{"K": "V"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got env %v, want %v", got, want)
+	}
+}
+
+func TestServer_DescribeConfigurationSettings(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	c := s.NewClient()
+
+	s.AddEnvironment(elasticbeanstalk.EnvironmentDescription{ApplicationName: "app", EnvironmentName: "env", Status: "Ready"}, elasticbeanstalk.ConfigurationOptionSettings{
+		{Namespace: "aws:elasticbeanstalk:application:environment", OptionName: "K", Value: "V"},
+	})
+
+	cs, err := c.DescribeConfigurationSettings(&elasticbeanstalk.DescribeConfigurationSettingsParams{ApplicationName: "app", EnvironmentName: "env"})
+	if err != nil {
+		t.Fatalf("DescribeConfigurationSettings returned error: %v", err)
+	}
+	if got, want := cs.Environ(), map[string]string{"K": "V"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got env %v, want %v", got, want)
+	}
+}
+
+func TestServer_UpdateEnvironment_OptionsToRemove(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	c := s.NewClient()
+
+	s.AddEnvironment(elasticbeanstalk.EnvironmentDescription{ApplicationName: "app", EnvironmentName: "env", Status: "Ready"}, elasticbeanstalk.ConfigurationOptionSettings{
+		{Namespace: "aws:elasticbeanstalk:application:environment", OptionName: "K0", Value: "V0"},
+		{Namespace: "aws:elasticbeanstalk:application:environment", OptionName: "K1", Value: "V1"},
+	})
+
+	p := &elasticbeanstalk.UpdateEnvironmentParams{EnvironmentName: "env"}
+	p.RemoveEnv("K0")
+	p.AddEnv("K2", "V2")
+	if err := c.UpdateEnvironment(p); err != nil {
+		t.Fatalf("UpdateEnvironment returned error: %v", err)
+	}
+	if got, want := s.OptionSettings("env").Environ(), map[string]string{"K1": "V1", "K2": "V2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got env %v, want %v", got, want)
+	}
+}
+
+func TestServer_faults(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	c := s.NewClient()
+
+	s.Throttle("DescribeEnvironments", 1)
+	s.FailWith5xx("", 1)
+
+	_, err := c.DescribeEnvironments(&elasticbeanstalk.DescribeEnvironmentsParams{})
+	if err == nil || !strings.Contains(err.Error(), "Throttling") {
+		t.Errorf("got error %v, want throttling error", err)
+	}
+	_, err = c.DescribeEnvironments(&elasticbeanstalk.DescribeEnvironmentsParams{})
+	if err == nil || !strings.Contains(err.Error(), "500") {
+		t.Errorf("got error %v, want 500 error", err)
+	}
+	if _, err := c.DescribeEnvironments(&elasticbeanstalk.DescribeEnvironmentsParams{}); err != nil {
+		t.Errorf("DescribeEnvironments returned error after faults were used up: %v", err)
+	}
+	if got, want := s.Requests("DescribeEnvironments"), 3; got != want {
+		t.Errorf("got %d requests, want %d", got, want)
+	}
+}
+
+func TestServer_events(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+
+	s.AddEnvironment(elasticbeanstalk.EnvironmentDescription{ApplicationName: "app", EnvironmentName: "env"}, nil)
+	s.Advance()
+
+	evs := s.Events()
+	if len(evs) != 2 {
+		t.Fatalf("got %d events, want 2", len(evs))
+	}
+	if want := "Successfully launched environment: env"; evs[0].Message != want {
+		t.Errorf("got newest event message %q, want %q", evs[0].Message, want)
+	}
+}
+
+func TestServer_DescribeEvents_paging(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	c := s.NewClient()
+
+	s.AddEnvironment(elasticbeanstalk.EnvironmentDescription{ApplicationName: "app", EnvironmentName: "env", Status: "Ready"}, nil)
+	for _, msg := range []string{"e1", "e2", "e3"} {
+		s.AddEvent("app", "env", "INFO", msg)
+	}
+
+	var got []string
+	p := &elasticbeanstalk.DescribeEventsParams{EnvironmentName: "env", MaxRecords: 2}
+	for pages := 0; ; pages++ {
+		if pages > 2 {
+			t.Fatal("too many pages")
+		}
+		res, err := c.DescribeEvents(p)
+		if err != nil {
+			t.Fatalf("DescribeEvents returned error: %v", err)
+		}
+		for _, ev := range res.Events {
+			got = append(got, ev.Message)
+		}
+		if res.NextToken == "" {
+			break
+		}
+		p.NextToken = res.NextToken
+	}
+	if want := []string{"e3", "e2", "e1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got events %v, want %v", got, want)
+	}
+}
+
+func TestServer_DescribeEnvironmentHealth(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	c := s.NewClient()
+
+	s.AddEnvironment(elasticbeanstalk.EnvironmentDescription{ApplicationName: "app", EnvironmentName: "env", Status: "Ready", Health: "Green"}, nil)
+	s.SetHealth("env", "Severe", "100% of requests are failing.")
+
+	h, err := c.DescribeEnvironmentHealth(&elasticbeanstalk.DescribeEnvironmentHealthParams{EnvironmentName: "env"})
+	if err != nil {
+		t.Fatalf("DescribeEnvironmentHealth returned error: %v", err)
+	}
+	if h.HealthStatus != "Severe" || h.Color != "Red" || !reflect.DeepEqual(h.Causes, []string{"100% of requests are failing."}) {
+		t.Errorf("got health %+v", h)
+	}
+}
+
+func TestServer_EnvironmentInfo(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	c := s.NewClient()
+
+	s.AddEnvironment(elasticbeanstalk.EnvironmentDescription{ApplicationName: "app", EnvironmentName: "env", Status: "Ready"}, nil)
+	s.SetInstanceLogs("env", "i-1", "tail log\n", nil)
+
+	// Info is only available after it is requested.
+	p := &elasticbeanstalk.RetrieveEnvironmentInfoParams{EnvironmentName: "env", InfoType: elasticbeanstalk.InfoTail}
+	info, err := c.RetrieveEnvironmentInfo(p)
+	if err != nil {
+		t.Fatalf("RetrieveEnvironmentInfo returned error: %v", err)
+	}
+	if len(info) != 0 {
+		t.Errorf("got %d info before request, want 0", len(info))
+	}
+
+	if err := c.RequestEnvironmentInfo(&elasticbeanstalk.RequestEnvironmentInfoParams{EnvironmentName: "env", InfoType: elasticbeanstalk.InfoTail}); err != nil {
+		t.Fatalf("RequestEnvironmentInfo returned error: %v", err)
+	}
+	info, err = c.RetrieveEnvironmentInfo(p)
+	if err != nil {
+		t.Fatalf("RetrieveEnvironmentInfo returned error: %v", err)
+	}
+	if len(info) != 1 || info[0].Ec2InstanceId != "i-1" {
+		t.Fatalf("got info %+v, want 1 for i-1", info)
+	}
+
+	resp, err := http.Get(info[0].Message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if string(body) != "tail log\n" {
+		t.Errorf("got log %q, want %q", body, "tail log\n")
+	}
+}
+
+func TestServer_ApplicationVersions(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	c := s.NewClient()
+
+	s.AddApplicationVersion(elasticbeanstalk.ApplicationVersionDescription{ApplicationName: "app", VersionLabel: "v1", DateCreated: elasticbeanstalk.Time{Time: time.Now().Add(-time.Hour)}})
+	s.AddApplicationVersion(elasticbeanstalk.ApplicationVersionDescription{ApplicationName: "app", VersionLabel: "v2"})
+	s.AddEnvironment(elasticbeanstalk.EnvironmentDescription{ApplicationName: "app", EnvironmentName: "env", Status: "Ready", VersionLabel: "v2"}, nil)
+
+	res, err := c.DescribeApplicationVersions(&elasticbeanstalk.DescribeApplicationVersionsParams{ApplicationName: "app", MaxRecords: 1})
+	if err != nil {
+		t.Fatalf("DescribeApplicationVersions returned error: %v", err)
+	}
+	if len(res.ApplicationVersions) != 1 || res.ApplicationVersions[0].VersionLabel != "v2" || res.NextToken == "" {
+		t.Fatalf("got first page %+v, want v2 and a next token", res)
+	}
+	res, err = c.DescribeApplicationVersions(&elasticbeanstalk.DescribeApplicationVersionsParams{ApplicationName: "app", MaxRecords: 1, NextToken: res.NextToken})
+	if err != nil {
+		t.Fatalf("DescribeApplicationVersions returned error: %v", err)
+	}
+	if len(res.ApplicationVersions) != 1 || res.ApplicationVersions[0].VersionLabel != "v1" || res.NextToken != "" {
+		t.Fatalf("got second page %+v, want only v1", res)
+	}
+
+	// Deployed versions can't be deleted.
+	if err := c.DeleteApplicationVersion(&elasticbeanstalk.DeleteApplicationVersionParams{ApplicationName: "app", VersionLabel: "v2"}); err == nil {
+		t.Error("DeleteApplicationVersion of deployed version succeeded, want error")
+	}
+	if err := c.DeleteApplicationVersion(&elasticbeanstalk.DeleteApplicationVersionParams{ApplicationName: "app", VersionLabel: "v1"}); err != nil {
+		t.Fatalf("DeleteApplicationVersion returned error: %v", err)
+	}
+	if vs := s.ApplicationVersions("app"); len(vs) != 1 || vs[0].VersionLabel != "v2" {
+		t.Errorf("got versions %+v after delete, want only v2", vs)
+	}
+}
+
+func TestServer_SwapEnvironmentCNAMEs(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	c := s.NewClient()
+
+	s.AddEnvironment(elasticbeanstalk.EnvironmentDescription{ApplicationName: "app", EnvironmentName: "blue", CNAME: "prod.example.com", Status: "Ready"}, nil)
+	s.AddEnvironment(elasticbeanstalk.EnvironmentDescription{ApplicationName: "app", EnvironmentName: "green", CNAME: "green.example.com", Status: "Ready"}, nil)
+
+	if err := c.SwapEnvironmentCNAMEs(&elasticbeanstalk.SwapEnvironmentCNAMEsParams{SourceEnvironmentName: "green", DestinationEnvironmentName: "blue"}); err != nil {
+		t.Fatalf("SwapEnvironmentCNAMEs returned error: %v", err)
+	}
+	blue, _ := s.Environment("blue")
+	green, _ := s.Environment("green")
+	if blue.CNAME != "green.example.com" || green.CNAME != "prod.example.com" {
+		t.Errorf("got CNAMEs blue=%q green=%q, want them swapped", blue.CNAME, green.CNAME)
+	}
+
+	// Both environments are updating, so they can't be swapped again yet.
+	if err := c.SwapEnvironmentCNAMEs(&elasticbeanstalk.SwapEnvironmentCNAMEsParams{SourceEnvironmentName: "blue", DestinationEnvironmentName: "green"}); err == nil {
+		t.Error("SwapEnvironmentCNAMEs of updating environments succeeded, want error")
+	}
+}
+
+func TestServer_CreateAndTerminateEnvironment(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	c := s.NewClient()
+
+	s.AddApplication("app")
+	s.AddEnvironment(elasticbeanstalk.EnvironmentDescription{ApplicationName: "app", EnvironmentName: "prod", CNAME: "myapp.elasticbeanstalk.com", Status: "Ready"}, nil)
+
+	res, err := c.CheckDNSAvailability(&elasticbeanstalk.CheckDNSAvailabilityParams{CNAMEPrefix: "MyApp"})
+	if err != nil {
+		t.Fatalf("CheckDNSAvailability returned error: %v", err)
+	}
+	if res.Available {
+		t.Error("CheckDNSAvailability of a CNAME in use returned Available")
+	}
+
+	p := &elasticbeanstalk.CreateEnvironmentParams{ApplicationName: "app", EnvironmentName: "staging", CNAMEPrefix: "myapp", SolutionStackName: "Go"}
+	if err := c.CreateEnvironment(p); err == nil {
+		t.Error("CreateEnvironment with a CNAME in use succeeded, want error")
+	}
+	p.CNAMEPrefix = "myapp-staging"
+	p.OptionSettings.Set(elasticbeanstalk.EnvKey("K"), "V")
+	if err := c.CreateEnvironment(p); err != nil {
+		t.Fatalf("CreateEnvironment returned error: %v", err)
+	}
+	if err := c.CreateEnvironment(p); err == nil {
+		t.Error("CreateEnvironment of an existing environment succeeded, want error")
+	}
+	staging, _ := s.Environment("staging")
+	if staging.Status != "Launching" || staging.CNAME != "myapp-staging.elasticbeanstalk.com" {
+		t.Errorf("got staging status %q CNAME %q, want Launching and myapp-staging.elasticbeanstalk.com", staging.Status, staging.CNAME)
+	}
+	if env := s.OptionSettings("staging").Environ(); env["K"] != "V" {
+		t.Errorf("got staging env vars %v, want K=V", env)
+	}
+
+	if err := c.TerminateEnvironment(&elasticbeanstalk.TerminateEnvironmentParams{EnvironmentName: "prod"}); err != nil {
+		t.Fatalf("TerminateEnvironment returned error: %v", err)
+	}
+	w := &elasticbeanstalk.EnvironmentWaiter{API: c, ApplicationName: "app", EnvironmentName: "prod", Interval: time.Millisecond}
+	if _, err := w.Wait("Terminated"); err != nil {
+		t.Fatalf("Wait returned error: %v", err)
+	}
+
+	// The terminated environment's CNAME is available again.
+	res, err = c.CheckDNSAvailability(&elasticbeanstalk.CheckDNSAvailabilityParams{CNAMEPrefix: "myapp"})
+	if err != nil {
+		t.Fatalf("CheckDNSAvailability returned error: %v", err)
+	}
+	if !res.Available || res.FullyQualifiedCNAME != "myapp.elasticbeanstalk.com" {
+		t.Errorf("CheckDNSAvailability after terminating returned %+v, want available myapp.elasticbeanstalk.com", res)
+	}
+}
@@ -30,6 +30,7 @@ type EnvironmentDescription struct {
 	EnvironmentId     string
 	EnvironmentName   string
 	Health            string
+	HealthStatus      string `json:",omitempty"`
 	SolutionStackName string
 	Status            string
 	TemplateName      string
@@ -69,6 +70,31 @@ func (c *Client) DescribeEnvironments(params *DescribeEnvironmentsParams) ([]*En
 	return o.DescribeEnvironmentsResponse.DescribeEnvironmentsResult.Environments, err
 }
 
+// CurrentEnvironment returns the environment in envs that isn't terminated
+// or, if they are all terminated, the most recently updated one. It returns
+// nil if envs is empty.
+//
+// DescribeEnvironments also returns recently terminated environments, so an
+// environment name may match both a terminated environment and the new one
+// that replaced it.
+func CurrentEnvironment(envs []*EnvironmentDescription) *EnvironmentDescription {
+	var cur *EnvironmentDescription
+	for _, e := range envs {
+		if cur == nil {
+			cur = e
+			continue
+		}
+		if terminated, curTerminated := e.Status == "Terminated", cur.Status == "Terminated"; terminated != curTerminated {
+			if !terminated {
+				cur = e
+			}
+		} else if e.DateUpdated.After(cur.DateUpdated.Time) {
+			cur = e
+		}
+	}
+	return cur
+}
+
 // A ConfigurationSettingsDescription describes the settings for a
 // configuration.
 //
@@ -92,16 +118,34 @@ type ConfigurationSettingsDescription struct {
 type ConfigurationSettings []*ConfigurationSettingsDescription
 
 // Environ returns a map of all environment variables set in the
-// configuration settings.
+// configuration settings. If more than one description sets a variable,
+// the value from the last description wins (see OptionSettings for control
+// over precedence).
 func (s ConfigurationSettings) Environ() map[string]string {
-	m := map[string]string{}
+	return s.OptionSettings(Overwrite).Environ()
+}
+
+// OptionSettings returns the option settings of all descriptions merged, in
+// order, according to prec. With Overwrite, later descriptions take
+// precedence; with KeepExisting, earlier ones do.
+func (s ConfigurationSettings) OptionSettings(prec Precedence) ConfigurationOptionSettings {
+	var opts ConfigurationOptionSettings
+	for _, csd := range s {
+		opts = opts.Merge(csd.OptionSettings, prec)
+	}
+	return opts
+}
+
+// Deployed returns the description of the currently deployed
+// configuration (whose DeploymentStatus is "deployed"), or nil if there is
+// none.
+func (s ConfigurationSettings) Deployed() *ConfigurationSettingsDescription {
 	for _, csd := range s {
-		m0 := csd.OptionSettings.Environ()
-		for k, v := range m0 {
-			m[k] = v
+		if csd.DeploymentStatus == "deployed" {
+			return csd
 		}
 	}
-	return m
+	return nil
 }
 
 // DescribeConfigurationSettingsParams specifies parameters for a
@@ -152,10 +196,27 @@ type UpdateEnvironmentParams struct {
 	EnvironmentName string
 	VersionLabel    string `url:",omitempty"`
 
-	OptionSettings ConfigurationOptionSettings `url:"-"`
+	OptionSettings  ConfigurationOptionSettings `url:"-"`
+	OptionsToRemove []OptionSpecification       `url:"-"`
 }
 
-const envVarNamespace = "aws:elasticbeanstalk:application:environment"
+// OptionSpecification identifies a configuration option, such as one to
+// remove from an environment.
+//
+// See
+// http://docs.aws.amazon.com/elasticbeanstalk/latest/api/API_OptionSpecification.html.
+type OptionSpecification struct {
+	Namespace    string
+	OptionName   string
+	ResourceName string `json:",omitempty"`
+}
+
+// Key returns the key that identifies the option.
+func (o OptionSpecification) Key() OptionKey {
+	return OptionKey{Namespace: o.Namespace, ResourceName: o.ResourceName, OptionName: o.OptionName}
+}
+
+const envVarNamespace = NamespaceApplicationEnvironment
 
 // AddEnv adds the specified environment variable name and value to
 // OptionSettings.
@@ -167,44 +228,210 @@ func (p *UpdateEnvironmentParams) AddEnv(name, value string) {
 	})
 }
 
-// optionSettingsValues returns a url.Values for the
-// (UpdateEnvironmentParams).OptionSettings field entries. Each entry yields 3
-// keys whose names are prefixed with `OptionSettings.member.N.`.
-func (p *UpdateEnvironmentParams) optionSettingsValues() url.Values {
-	if len(p.OptionSettings) == 0 {
+// RemoveEnv adds the specified environment variable name to
+// OptionsToRemove, so that the variable is unset.
+func (p *UpdateEnvironmentParams) RemoveEnv(name string) {
+	p.OptionsToRemove = append(p.OptionsToRemove, OptionSpecification{
+		Namespace:  envVarNamespace,
+		OptionName: name,
+	})
+}
+
+// optionSettingsValues returns a url.Values for the OptionSettings field
+// entries of UpdateEnvironmentParams or CreateEnvironmentParams. Each entry
+// yields 3 keys (4 if ResourceName is set) whose names are prefixed with
+// `OptionSettings.member.N.`.
+func optionSettingsValues(opts ConfigurationOptionSettings) url.Values {
+	if len(opts) == 0 {
 		return nil
 	}
 	v := make(url.Values)
-	for i, s := range p.OptionSettings {
+	for i, s := range opts {
 		kp := fmt.Sprintf("OptionSettings.member.%d", i+1)
 		v.Set(kp+".Namespace", s.Namespace)
 		v.Set(kp+".OptionName", s.OptionName)
+		if s.ResourceName != "" {
+			v.Set(kp+".ResourceName", s.ResourceName)
+		}
 		v.Set(kp+".Value", s.Value)
 	}
 	return v
 }
 
+// optionsToRemoveValues returns a url.Values for the
+// (UpdateEnvironmentParams).OptionsToRemove field entries. Each entry yields
+// 2 keys (3 if ResourceName is set) whose names are prefixed with
+// `OptionsToRemove.member.N.`.
+func (p *UpdateEnvironmentParams) optionsToRemoveValues() url.Values {
+	if len(p.OptionsToRemove) == 0 {
+		return nil
+	}
+	v := make(url.Values)
+	for i, o := range p.OptionsToRemove {
+		kp := fmt.Sprintf("OptionsToRemove.member.%d", i+1)
+		v.Set(kp+".Namespace", o.Namespace)
+		v.Set(kp+".OptionName", o.OptionName)
+		if o.ResourceName != "" {
+			v.Set(kp+".ResourceName", o.ResourceName)
+		}
+	}
+	return v
+}
+
 // ConfigurationOptionSetting is a specification identifying an individual
 // configuration option along with its current value.
 //
 // See
 // http://docs.aws.amazon.com/elasticbeanstalk/latest/api/API_ConfigurationOptionSetting.html.
 type ConfigurationOptionSetting struct {
-	Namespace  string
-	OptionName string
-	Value      string
+	Namespace    string
+	OptionName   string
+	ResourceName string `json:",omitempty"`
+	Value        string
 }
 
+// UpdateEnvironment updates an environment's application version or
+// configuration. The environment variables in params are checked with
+// ValidateEnv before the request is sent.
+//
+// See
+// http://docs.aws.amazon.com/elasticbeanstalk/latest/api/API_UpdateEnvironment.html.
 func (c *Client) UpdateEnvironment(params *UpdateEnvironmentParams) error {
+	if err := params.ValidateEnv(); err != nil {
+		return err
+	}
+
 	v, err := query.Values(params)
 	if err != nil {
 		return err
 	}
 
-	osv := params.optionSettingsValues()
-	for k, vs := range osv {
+	for k, vs := range optionSettingsValues(params.OptionSettings) {
+		v[k] = vs
+	}
+	for k, vs := range params.optionsToRemoveValues() {
 		v[k] = vs
 	}
 
 	return c.Do("POST", "UpdateEnvironment", v, nil)
 }
+
+// SwapEnvironmentCNAMEsParams specifies parameters for
+// SwapEnvironmentCNAMEs.
+//
+// See
+// http://docs.aws.amazon.com/elasticbeanstalk/latest/api/API_SwapEnvironmentCNAMEs.html.
+type SwapEnvironmentCNAMEsParams struct {
+	SourceEnvironmentName      string `url:",omitempty"`
+	SourceEnvironmentId        string `url:",omitempty"`
+	DestinationEnvironmentName string `url:",omitempty"`
+	DestinationEnvironmentId   string `url:",omitempty"`
+}
+
+// SwapEnvironmentCNAMEs swaps the CNAMEs of two environments, such as to
+// move traffic to a newly deployed environment in a blue/green deployment.
+//
+// See
+// http://docs.aws.amazon.com/elasticbeanstalk/latest/api/API_SwapEnvironmentCNAMEs.html.
+func (c *Client) SwapEnvironmentCNAMEs(params *SwapEnvironmentCNAMEsParams) error {
+	v, err := query.Values(params)
+	if err != nil {
+		return err
+	}
+	return c.Do("POST", "SwapEnvironmentCNAMEs", v, nil)
+}
+
+// CheckDNSAvailabilityParams specifies parameters for CheckDNSAvailability.
+//
+// See
+// http://docs.aws.amazon.com/elasticbeanstalk/latest/api/API_CheckDNSAvailability.html.
+type CheckDNSAvailabilityParams struct {
+	CNAMEPrefix string
+}
+
+// CheckDNSAvailabilityResult is the result of CheckDNSAvailability.
+//
+// See
+// http://docs.aws.amazon.com/elasticbeanstalk/latest/api/API_CheckDNSAvailability.html.
+type CheckDNSAvailabilityResult struct {
+	Available           bool
+	FullyQualifiedCNAME string
+}
+
+// CheckDNSAvailability checks whether a CNAME prefix is available for a new
+// environment.
+//
+// See
+// http://docs.aws.amazon.com/elasticbeanstalk/latest/api/API_CheckDNSAvailability.html.
+func (c *Client) CheckDNSAvailability(params *CheckDNSAvailabilityParams) (*CheckDNSAvailabilityResult, error) {
+	v, err := query.Values(params)
+	if err != nil {
+		return nil, err
+	}
+	var o struct {
+		CheckDNSAvailabilityResponse struct {
+			CheckDNSAvailabilityResult *CheckDNSAvailabilityResult
+		}
+	}
+	err = c.Do("GET", "CheckDNSAvailability", v, &o)
+	return o.CheckDNSAvailabilityResponse.CheckDNSAvailabilityResult, err
+}
+
+// CreateEnvironmentParams specifies parameters for CreateEnvironment.
+// Exactly one of SolutionStackName and TemplateName must be set.
+//
+// See
+// http://docs.aws.amazon.com/elasticbeanstalk/latest/api/API_CreateEnvironment.html.
+type CreateEnvironmentParams struct {
+	ApplicationName   string
+	EnvironmentName   string
+	CNAMEPrefix       string `url:",omitempty"`
+	Description       string `url:",omitempty"`
+	SolutionStackName string `url:",omitempty"`
+	TemplateName      string `url:",omitempty"`
+	VersionLabel      string `url:",omitempty"`
+
+	OptionSettings ConfigurationOptionSettings `url:"-"`
+}
+
+// CreateEnvironment launches a new environment. The environment variables in
+// params are checked with ValidateEnv before the request is sent.
+//
+// See
+// http://docs.aws.amazon.com/elasticbeanstalk/latest/api/API_CreateEnvironment.html.
+func (c *Client) CreateEnvironment(params *CreateEnvironmentParams) error {
+	if err := params.OptionSettings.ValidateEnv(); err != nil {
+		return err
+	}
+
+	v, err := query.Values(params)
+	if err != nil {
+		return err
+	}
+	for k, vs := range optionSettingsValues(params.OptionSettings) {
+		v[k] = vs
+	}
+	return c.Do("POST", "CreateEnvironment", v, nil)
+}
+
+// TerminateEnvironmentParams specifies parameters for TerminateEnvironment.
+//
+// See
+// http://docs.aws.amazon.com/elasticbeanstalk/latest/api/API_TerminateEnvironment.html.
+type TerminateEnvironmentParams struct {
+	EnvironmentName string `url:",omitempty"`
+	EnvironmentId   string `url:",omitempty"`
+}
+
+// TerminateEnvironment terminates an environment and the AWS resources
+// (such as instances and load balancers) that it uses.
+//
+// See
+// http://docs.aws.amazon.com/elasticbeanstalk/latest/api/API_TerminateEnvironment.html.
+func (c *Client) TerminateEnvironment(params *TerminateEnvironmentParams) error {
+	v, err := query.Values(params)
+	if err != nil {
+		return err
+	}
+	return c.Do("POST", "TerminateEnvironment", v, nil)
+}
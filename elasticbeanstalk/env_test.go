@@ -83,6 +83,29 @@ func TestDescribeEnvironments(t *testing.T) {
 	}
 }
 
+func TestCurrentEnvironment(t *testing.T) {
+	t0 := time.Date(2014, 2, 28, 0, 0, 0, 0, time.UTC)
+	old := &EnvironmentDescription{EnvironmentId: "e-1", Status: "Terminated", DateUpdated: Time{t0.Add(time.Hour)}}
+	older := &EnvironmentDescription{EnvironmentId: "e-0", Status: "Terminated", DateUpdated: Time{t0}}
+	cur := &EnvironmentDescription{EnvironmentId: "e-2", Status: "Launching", DateUpdated: Time{t0}}
+
+	tests := []struct {
+		envs []*EnvironmentDescription
+		want *EnvironmentDescription
+	}{
+		{nil, nil},
+		{[]*EnvironmentDescription{cur}, cur},
+		{[]*EnvironmentDescription{old, cur}, cur},
+		{[]*EnvironmentDescription{cur, old}, cur},
+		{[]*EnvironmentDescription{older, old}, old},
+	}
+	for _, test := range tests {
+		if got := CurrentEnvironment(test.envs); got != test.want {
+			t.Errorf("CurrentEnvironment(%s) = %s, want %s", asJSON(t, test.envs), asJSON(t, got), asJSON(t, test.want))
+		}
+	}
+}
+
 func TestConfigurationSettings_Environ(t *testing.T) {
 	got := ConfigurationSettings{
 		{
@@ -216,3 +239,159 @@ func TestUpdateEnvironment_OptionSettings_Env(t *testing.T) {
 		t.Errorf("UpdateEnvironment returned error: %v", err)
 	}
 }
+
+func TestUpdateEnvironment_OptionsToRemove(t *testing.T) {
+	setup()
+	defer teardown()
+
+	wantParams := url.Values{
+		"Operation":                             []string{"UpdateEnvironment"},
+		"EnvironmentName":                       []string{"env"},
+		"OptionsToRemove.member.1.Namespace":    []string{"aws:elasticbeanstalk:application:environment"},
+		"OptionsToRemove.member.1.OptionName":   []string{"K0"},
+		"OptionsToRemove.member.2.Namespace":    []string{"aws:elasticbeanstalk:command"},
+		"OptionsToRemove.member.2.OptionName":   []string{"Timeout"},
+		"OptionsToRemove.member.2.ResourceName": []string{"R"},
+	}
+
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+		if p := r.URL.Query(); !reflect.DeepEqual(p, wantParams) {
+			t.Errorf("UpdateEnvironment got params %# v, want %# v", pretty.Formatter(p), pretty.Formatter(wantParams))
+		}
+	})
+
+	p := &UpdateEnvironmentParams{EnvironmentName: "env"}
+	p.RemoveEnv("K0")
+	p.OptionsToRemove = append(p.OptionsToRemove, OptionSpecification{Namespace: NamespaceCommand, OptionName: "Timeout", ResourceName: "R"})
+	err := client.UpdateEnvironment(p)
+	if err != nil {
+		t.Errorf("UpdateEnvironment returned error: %v", err)
+	}
+}
+
+func TestSwapEnvironmentCNAMEs(t *testing.T) {
+	setup()
+	defer teardown()
+
+	wantParams := url.Values{
+		"Operation":                  []string{"SwapEnvironmentCNAMEs"},
+		"SourceEnvironmentName":      []string{"green"},
+		"DestinationEnvironmentName": []string{"blue"},
+	}
+
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+		if p := r.URL.Query(); !reflect.DeepEqual(p, wantParams) {
+			t.Errorf("SwapEnvironmentCNAMEs got params %# v, want %# v", pretty.Formatter(p), pretty.Formatter(wantParams))
+		}
+		writeJSON(w, `{}`)
+	})
+
+	err := client.SwapEnvironmentCNAMEs(&SwapEnvironmentCNAMEsParams{SourceEnvironmentName: "green", DestinationEnvironmentName: "blue"})
+	if err != nil {
+		t.Errorf("SwapEnvironmentCNAMEs returned error: %v", err)
+	}
+}
+
+func TestCheckDNSAvailability(t *testing.T) {
+	setup()
+	defer teardown()
+
+	wantParams := url.Values{
+		"Operation":   []string{"CheckDNSAvailability"},
+		"CNAMEPrefix": []string{"myapp"},
+	}
+
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		if p := r.URL.Query(); !reflect.DeepEqual(p, wantParams) {
+			t.Errorf("CheckDNSAvailability got params %# v, want %# v", pretty.Formatter(p), pretty.Formatter(wantParams))
+		}
+		writeJSON(w, `{"CheckDNSAvailabilityResponse":{"CheckDNSAvailabilityResult":{"Available":true,"FullyQualifiedCNAME":"myapp.us-west-2.elasticbeanstalk.com"}}}`)
+	})
+
+	res, err := client.CheckDNSAvailability(&CheckDNSAvailabilityParams{CNAMEPrefix: "myapp"})
+	if err != nil {
+		t.Errorf("CheckDNSAvailability returned error: %v", err)
+	}
+
+	want := &CheckDNSAvailabilityResult{Available: true, FullyQualifiedCNAME: "myapp.us-west-2.elasticbeanstalk.com"}
+	if !reflect.DeepEqual(res, want) {
+		t.Errorf("CheckDNSAvailability returned %+v, want %+v", res, want)
+	}
+}
+
+func TestCreateEnvironment(t *testing.T) {
+	setup()
+	defer teardown()
+
+	wantParams := url.Values{
+		"Operation":                          []string{"CreateEnvironment"},
+		"ApplicationName":                    []string{"app"},
+		"EnvironmentName":                    []string{"env"},
+		"CNAMEPrefix":                        []string{"myapp"},
+		"SolutionStackName":                  []string{"64bit Amazon Linux 2 v3.4.0 running Go 1"},
+		"OptionSettings.member.1.Namespace":  []string{"aws:elasticbeanstalk:application:environment"},
+		"OptionSettings.member.1.OptionName": []string{"K0"},
+		"OptionSettings.member.1.Value":      []string{"V0"},
+	}
+
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+		if p := r.URL.Query(); !reflect.DeepEqual(p, wantParams) {
+			t.Errorf("CreateEnvironment got params %# v, want %# v", pretty.Formatter(p), pretty.Formatter(wantParams))
+		}
+		writeJSON(w, `{}`)
+	})
+
+	p := &CreateEnvironmentParams{
+		ApplicationName:   "app",
+		EnvironmentName:   "env",
+		CNAMEPrefix:       "myapp",
+		SolutionStackName: "64bit Amazon Linux 2 v3.4.0 running Go 1",
+	}
+	p.OptionSettings.Set(EnvKey("K0"), "V0")
+	err := client.CreateEnvironment(p)
+	if err != nil {
+		t.Errorf("CreateEnvironment returned error: %v", err)
+	}
+}
+
+func TestCreateEnvironment_InvalidEnv(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		t.Error("CreateEnvironment sent a request with invalid environment variables")
+	})
+
+	p := &CreateEnvironmentParams{ApplicationName: "app", EnvironmentName: "env", TemplateName: "t"}
+	p.OptionSettings.Set(EnvKey("BAD NAME"), "V0")
+	if _, ok := client.CreateEnvironment(p).(*EnvError); !ok {
+		t.Error("CreateEnvironment did not return an *EnvError")
+	}
+}
+
+func TestTerminateEnvironment(t *testing.T) {
+	setup()
+	defer teardown()
+
+	wantParams := url.Values{
+		"Operation":       []string{"TerminateEnvironment"},
+		"EnvironmentName": []string{"env"},
+	}
+
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+		if p := r.URL.Query(); !reflect.DeepEqual(p, wantParams) {
+			t.Errorf("TerminateEnvironment got params %# v, want %# v", pretty.Formatter(p), pretty.Formatter(wantParams))
+		}
+		writeJSON(w, `{}`)
+	})
+
+	err := client.TerminateEnvironment(&TerminateEnvironmentParams{EnvironmentName: "env"})
+	if err != nil {
+		t.Errorf("TerminateEnvironment returned error: %v", err)
+	}
+}
@@ -0,0 +1,89 @@
+package elasticbeanstalk
+
+import (
+	"github.com/google/go-querystring/query"
+)
+
+// Types of environment information that can be requested with
+// RequestEnvironmentInfo.
+const (
+	// InfoTail is the last 100 lines of the most important log files of
+	// each instance.
+	InfoTail = "tail"
+
+	// InfoBundle is a zip archive of all of the log files of each instance.
+	InfoBundle = "bundle"
+)
+
+// RequestEnvironmentInfoParams specifies parameters for
+// RequestEnvironmentInfo.
+//
+// See
+// http://docs.aws.amazon.com/elasticbeanstalk/latest/api/API_RequestEnvironmentInfo.html.
+type RequestEnvironmentInfoParams struct {
+	EnvironmentName string `url:",omitempty"`
+	EnvironmentId   string `url:",omitempty"`
+	InfoType        string // InfoTail or InfoBundle
+}
+
+// RequestEnvironmentInfo starts collecting information (such as logs) from
+// each instance of an environment. Once it has been collected, which takes
+// some time, it is returned by RetrieveEnvironmentInfo.
+//
+// See
+// http://docs.aws.amazon.com/elasticbeanstalk/latest/api/API_RequestEnvironmentInfo.html.
+func (c *Client) RequestEnvironmentInfo(params *RequestEnvironmentInfoParams) error {
+	v, err := query.Values(params)
+	if err != nil {
+		return err
+	}
+	return c.Do("POST", "RequestEnvironmentInfo", v, nil)
+}
+
+// RetrieveEnvironmentInfoParams specifies parameters for
+// RetrieveEnvironmentInfo.
+//
+// See
+// http://docs.aws.amazon.com/elasticbeanstalk/latest/api/API_RetrieveEnvironmentInfo.html.
+type RetrieveEnvironmentInfoParams struct {
+	EnvironmentName string `url:",omitempty"`
+	EnvironmentId   string `url:",omitempty"`
+	InfoType        string // InfoTail or InfoBundle
+}
+
+// EnvironmentInfoDescription describes information collected from an
+// instance by RequestEnvironmentInfo.
+//
+// See
+// http://docs.aws.amazon.com/elasticbeanstalk/latest/api/API_EnvironmentInfoDescription.html.
+type EnvironmentInfoDescription struct {
+	Ec2InstanceId string
+	InfoType      string
+
+	// Message is a presigned URL from which the information can be
+	// downloaded.
+	Message string
+
+	SampleTimestamp Time
+}
+
+// RetrieveEnvironmentInfo returns the information most recently collected
+// from each instance of an environment by RequestEnvironmentInfo.
+//
+// See
+// http://docs.aws.amazon.com/elasticbeanstalk/latest/api/API_RetrieveEnvironmentInfo.html.
+func (c *Client) RetrieveEnvironmentInfo(params *RetrieveEnvironmentInfoParams) ([]*EnvironmentInfoDescription, error) {
+	v, err := query.Values(params)
+	if err != nil {
+		return nil, err
+	}
+	var o struct {
+		RetrieveEnvironmentInfoResponse struct {
+			RetrieveEnvironmentInfoResult struct {
+				EnvironmentInfo []*EnvironmentInfoDescription
+			}
+		}
+	}
+	err = c.Do("GET", "RetrieveEnvironmentInfo", v, &o)
+	return o.RetrieveEnvironmentInfoResponse.RetrieveEnvironmentInfoResult.EnvironmentInfo, err
+}
@@ -0,0 +1,96 @@
+package elasticbeanstalk
+
+import (
+	"net/http"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/kr/pretty"
+)
+
+func TestRequestEnvironmentInfo(t *testing.T) {
+	setup()
+	defer teardown()
+
+	wantParams := url.Values{
+		"Operation":       []string{"RequestEnvironmentInfo"},
+		"EnvironmentName": []string{"env"},
+		"InfoType":        []string{"tail"},
+	}
+
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+		if p := r.URL.Query(); !reflect.DeepEqual(p, wantParams) {
+			t.Errorf("RequestEnvironmentInfo got params %# v, want %# v", pretty.Formatter(p), pretty.Formatter(wantParams))
+		}
+		writeJSON(w, `{}`)
+	})
+
+	err := client.RequestEnvironmentInfo(&RequestEnvironmentInfoParams{EnvironmentName: "env", InfoType: InfoTail})
+	if err != nil {
+		t.Errorf("RequestEnvironmentInfo returned error: %v", err)
+	}
+}
+
+func TestRequestEnvironmentInfo_DryRun(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("RequestEnvironmentInfo was sent in dry-run mode")
+	})
+
+	// It makes the instances collect their logs, so it is only recorded.
+	client.DryRun = &DryRun{}
+	err := client.RequestEnvironmentInfo(&RequestEnvironmentInfoParams{EnvironmentName: "env", InfoType: InfoTail})
+	if err != nil {
+		t.Errorf("RequestEnvironmentInfo returned error: %v", err)
+	}
+	if ops := client.DryRun.Operations(); len(ops) != 1 || ops[0].Name != "RequestEnvironmentInfo" {
+		t.Errorf("got recorded operations %v, want RequestEnvironmentInfo", asJSON(t, ops))
+	}
+}
+
+func TestRetrieveEnvironmentInfo(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		if got := r.URL.Query().Get("InfoType"); got != "bundle" {
+			t.Errorf("got InfoType %q, want bundle", got)
+		}
+		writeJSON(w, `
+{
+    "RetrieveEnvironmentInfoResponse": {"RetrieveEnvironmentInfoResult": {"EnvironmentInfo": [
+        {
+            "Ec2InstanceId": "i-1234",
+            "InfoType": "bundle",
+            "Message": "https://example-bucket.s3.amazonaws.com/logs.zip?sig=x",
+            "SampleTimestamp": `+floatTime(t, "2014-02-28T00:33:47.684Z")+`
+        }
+    ]}}
+}
+`)
+	})
+
+	want := []*EnvironmentInfoDescription{
+		{
+			Ec2InstanceId:   "i-1234",
+			InfoType:        "bundle",
+			Message:         "https://example-bucket.s3.amazonaws.com/logs.zip?sig=x",
+			SampleTimestamp: mustParseTime(t, "2014-02-28T00:33:47.684Z"),
+		},
+	}
+
+	got, err := client.RetrieveEnvironmentInfo(&RetrieveEnvironmentInfoParams{EnvironmentName: "env", InfoType: InfoBundle})
+	if err != nil {
+		t.Fatalf("RetrieveEnvironmentInfo returned error: %v", err)
+	}
+	normTime(&want[0].SampleTimestamp)
+	normTime(&got[0].SampleTimestamp)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("RetrieveEnvironmentInfo returned %+v, want %+v", asJSON(t, got), asJSON(t, want))
+	}
+}
@@ -0,0 +1,82 @@
+package elasticbeanstalk
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Limits that Elastic Beanstalk enforces on environment variables
+// (environment properties).
+//
+// See
+// http://docs.aws.amazon.com/elasticbeanstalk/latest/dg/environments-cfg-softwaresettings.html.
+const (
+	MaxEnvNameLength  = 128
+	MaxEnvValueLength = 256
+
+	// MaxEnvTotalSize is the maximum combined size of all environment
+	// variables, each counted as len(name) + len("=") + len(value).
+	MaxEnvTotalSize = 4096
+)
+
+var envNamePattern = regexp.MustCompile(`^[A-Za-z0-9_.:/+\\@-]+$`)
+
+// An EnvError describes every problem found when validating environment
+// variables.
+type EnvError struct {
+	Violations []string
+}
+
+func (e *EnvError) Error() string {
+	return fmt.Sprintf("invalid environment variables (%d problems):\n - %s", len(e.Violations), strings.Join(e.Violations, "\n - "))
+}
+
+// ValidateEnv checks the environment variables in opts against the limits
+// that Elastic Beanstalk enforces: name syntax and length, value length,
+// duplicate names, and the combined size of all variables. It returns an
+// *EnvError listing every violation, or nil if there are none.
+//
+// The total size limit applies to all variables set on an environment, so
+// to check an update accurately, validate the environment's current settings
+// merged with the update's.
+func (opts ConfigurationOptionSettings) ValidateEnv() error {
+	var violations []string
+	seen := map[string]bool{}
+	total := 0
+	for _, o := range opts {
+		if o.Namespace != envVarNamespace {
+			continue
+		}
+		name := o.OptionName
+		switch {
+		case name == "":
+			violations = append(violations, "empty name")
+		case !envNamePattern.MatchString(name):
+			violations = append(violations, fmt.Sprintf("%q: name may only contain letters, digits, and _ . : / + \\ - @", name))
+		case len(name) > MaxEnvNameLength:
+			violations = append(violations, fmt.Sprintf("%q: name is %d characters long (max %d)", name, len(name), MaxEnvNameLength))
+		}
+		if seen[name] {
+			violations = append(violations, fmt.Sprintf("%q: set more than once", name))
+		}
+		seen[name] = true
+		if len(o.Value) > MaxEnvValueLength {
+			violations = append(violations, fmt.Sprintf("%q: value is %d characters long (max %d)", name, len(o.Value), MaxEnvValueLength))
+		}
+		total += len(name) + 1 + len(o.Value)
+	}
+	if total > MaxEnvTotalSize {
+		violations = append(violations, fmt.Sprintf("combined size of all variables is %d bytes (max %d)", total, MaxEnvTotalSize))
+	}
+	if len(violations) > 0 {
+		return &EnvError{Violations: violations}
+	}
+	return nil
+}
+
+// ValidateEnv checks the environment variables in p.OptionSettings. See
+// (ConfigurationOptionSettings).ValidateEnv.
+func (p *UpdateEnvironmentParams) ValidateEnv() error {
+	return p.OptionSettings.ValidateEnv()
+}
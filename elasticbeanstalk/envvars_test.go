@@ -0,0 +1,61 @@
+package elasticbeanstalk
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestConfigurationOptionSettings_ValidateEnv(t *testing.T) {
+	p := &UpdateEnvironmentParams{}
+	p.AddEnv("GOOD_name.1:/+\\-@", "v")
+	p.AddEnv("BAD NAME", "v")
+	p.AddEnv("DUP", "1")
+	p.AddEnv("DUP", "2")
+	p.AddEnv(strings.Repeat("N", MaxEnvNameLength+1), "")
+	p.AddEnv("LONG", strings.Repeat("v", MaxEnvValueLength+1))
+	p.AddOptions(&AutoScalingGroupOptions{MinSize: Int(1)})
+
+	err := p.ValidateEnv()
+	envErr, ok := err.(*EnvError)
+	if !ok {
+		t.Fatalf("got error %v, want *EnvError", err)
+	}
+	want := []string{
+		`"BAD NAME": name may only contain letters, digits, and _ . : / + \ - @`,
+		`"DUP": set more than once`,
+		`"` + strings.Repeat("N", MaxEnvNameLength+1) + `": name is 129 characters long (max 128)`,
+		`"LONG": value is 257 characters long (max 256)`,
+	}
+	if !reflect.DeepEqual(envErr.Violations, want) {
+		t.Errorf("got violations %q, want %q", envErr.Violations, want)
+	}
+}
+
+func TestConfigurationOptionSettings_ValidateEnv_totalSize(t *testing.T) {
+	p := &UpdateEnvironmentParams{}
+	for i := 0; i < 20; i++ {
+		p.AddEnv(string(rune('A'+i)), strings.Repeat("v", MaxEnvValueLength))
+	}
+	err := p.ValidateEnv()
+	if err == nil || !strings.Contains(err.Error(), "combined size of all variables is 5160 bytes (max 4096)") {
+		t.Errorf("got error %v, want total size error", err)
+	}
+
+	p = &UpdateEnvironmentParams{}
+	p.AddEnv("K", "V")
+	if err := p.ValidateEnv(); err != nil {
+		t.Errorf("got error %v for valid env", err)
+	}
+}
+
+func TestUpdateEnvironment_invalidEnv(t *testing.T) {
+	setup()
+	defer teardown()
+
+	p := &UpdateEnvironmentParams{EnvironmentName: "env"}
+	p.AddEnv("BAD NAME", "v")
+	if err := client.UpdateEnvironment(p); err == nil {
+		t.Error("UpdateEnvironment returned no error for invalid env")
+	}
+}
@@ -0,0 +1,190 @@
+package elasticbeanstalk
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/go-querystring/query"
+)
+
+// DescribeEventsParams specifies parameters for DescribeEvents.
+//
+// See
+// http://docs.aws.amazon.com/elasticbeanstalk/latest/api/API_DescribeEvents.html.
+type DescribeEventsParams struct {
+	ApplicationName string    `url:",omitempty"`
+	EnvironmentName string    `url:",omitempty"`
+	VersionLabel    string    `url:",omitempty"`
+	Severity        string    `url:",omitempty"` // minimum severity (such as "WARN")
+	StartTime       time.Time `url:",omitempty"`
+	EndTime         time.Time `url:",omitempty"`
+	MaxRecords      int       `url:",omitempty"`
+	NextToken       string    `url:",omitempty"`
+}
+
+// EventDescription describes an event.
+//
+// See
+// http://docs.aws.amazon.com/elasticbeanstalk/latest/api/API_EventDescription.html.
+type EventDescription struct {
+	ApplicationName string
+	EnvironmentName string `json:",omitempty"`
+	EventDate       Time
+	Message         string
+	RequestId       string `json:",omitempty"`
+	Severity        string
+	VersionLabel    string `json:",omitempty"`
+}
+
+// DescribeEventsResult is a page of events returned by DescribeEvents.
+type DescribeEventsResult struct {
+	// Events are the matching events, newest first.
+	Events []*EventDescription
+
+	// NextToken, if non-empty, is passed in DescribeEventsParams to get the
+	// next page of events.
+	NextToken string `json:",omitempty"`
+}
+
+// DescribeEvents returns a page of events matching params, newest first.
+//
+// See
+// http://docs.aws.amazon.com/elasticbeanstalk/latest/api/API_DescribeEvents.html.
+func (c *Client) DescribeEvents(params *DescribeEventsParams) (*DescribeEventsResult, error) {
+	v, err := query.Values(params)
+	if err != nil {
+		return nil, err
+	}
+	var o struct {
+		DescribeEventsResponse struct {
+			DescribeEventsResult DescribeEventsResult
+		}
+	}
+	if err := c.Do("GET", "DescribeEvents", v, &o); err != nil {
+		return nil, err
+	}
+	return &o.DescribeEventsResponse.DescribeEventsResult, nil
+}
+
+// An EventPoller returns the events matching Params that it hasn't already
+// returned, for following an environment's events as they occur. It is not
+// safe for concurrent use.
+type EventPoller struct {
+	API API
+
+	// Params filters the events to return. Params.StartTime is the time of
+	// the earliest event to return; each call to Poll advances it to the time
+	// of the latest event returned. Params.NextToken is ignored.
+	Params DescribeEventsParams
+
+	// seen holds the events already returned whose EventDate is not before
+	// Params.StartTime truncated to the second, which may be returned by
+	// DescribeEvents again (StartTime is sent without fractional seconds).
+	seen map[eventKey]bool
+}
+
+type eventKey struct {
+	date                                   time.Time
+	env, label, severity, message, request string
+}
+
+func keyOf(ev *EventDescription) eventKey {
+	return eventKey{ev.EventDate.Time, ev.EnvironmentName, ev.VersionLabel, ev.Severity, ev.Message, ev.RequestId}
+}
+
+// Poll returns the new matching events, oldest first. It fetches every page
+// of results.
+func (p *EventPoller) Poll() ([]*EventDescription, error) {
+	params := p.Params
+	params.NextToken = ""
+	var evs []*EventDescription
+	for {
+		res, err := p.API.DescribeEvents(&params)
+		if err != nil {
+			return nil, err
+		}
+		if res == nil {
+			// Treat a missing result as an empty page.
+			break
+		}
+		evs = append(evs, res.Events...)
+		if res.NextToken == "" {
+			break
+		}
+		params.NextToken = res.NextToken
+	}
+	// DescribeEvents returns events newest first.
+	for i, j := 0, len(evs)-1; i < j; i, j = i+1, j-1 {
+		evs[i], evs[j] = evs[j], evs[i]
+	}
+	sort.SliceStable(evs, func(i, j int) bool { return evs[i].EventDate.Before(evs[j].EventDate.Time) })
+
+	if p.seen == nil {
+		p.seen = map[eventKey]bool{}
+	}
+	var fresh []*EventDescription
+	for _, ev := range evs {
+		k := keyOf(ev)
+		if p.seen[k] {
+			continue
+		}
+		p.seen[k] = true
+		fresh = append(fresh, ev)
+	}
+
+	if len(fresh) > 0 {
+		// Events in the same second as the new start time will be returned
+		// again, because it is sent without fractional seconds, but earlier
+		// ones won't, so they needn't be remembered.
+		p.Params.StartTime = fresh[len(fresh)-1].EventDate.Time
+		since := p.Params.StartTime.Truncate(time.Second)
+		for k := range p.seen {
+			if k.date.Before(since) {
+				delete(p.seen, k)
+			}
+		}
+	}
+	return fresh, nil
+}
+
+// isDeployedMessage reports whether an event message indicates that the
+// event's version was successfully deployed to its environment.
+func isDeployedMessage(msg string) bool {
+	return msg == "Environment update completed successfully." ||
+		strings.HasPrefix(msg, "Successfully launched environment:")
+}
+
+// VersionHistory returns the labels of the versions that were successfully
+// deployed to an environment (by updating or launching it), most recent
+// first, based on the events matching params (which should specify the
+// environment). Each label appears once, at its most recent deployment.
+// Params.NextToken is ignored. Events are only kept for a limited time, so
+// the history is incomplete.
+func VersionHistory(api API, params DescribeEventsParams) ([]string, error) {
+	params.NextToken = ""
+	var labels []string
+	seen := map[string]bool{}
+	for {
+		res, err := api.DescribeEvents(&params)
+		if err != nil {
+			return nil, err
+		}
+		if res == nil {
+			// Treat a missing result as an empty page.
+			return labels, nil
+		}
+		// DescribeEvents returns events newest first.
+		for _, ev := range res.Events {
+			if ev.VersionLabel == "" || seen[ev.VersionLabel] || !isDeployedMessage(ev.Message) {
+				continue
+			}
+			seen[ev.VersionLabel] = true
+			labels = append(labels, ev.VersionLabel)
+		}
+		if res.NextToken == "" {
+			return labels, nil
+		}
+		params.NextToken = res.NextToken
+	}
+}
@@ -0,0 +1,274 @@
+package elasticbeanstalk
+
+import (
+	"net/http"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/kr/pretty"
+)
+
+func TestDescribeEvents(t *testing.T) {
+	setup()
+	defer teardown()
+
+	wantParams := url.Values{
+		"Operation":       []string{"DescribeEvents"},
+		"EnvironmentName": []string{"env"},
+		"StartTime":       []string{"2014-02-28T00:00:00Z"},
+		"MaxRecords":      []string{"2"},
+		"NextToken":       []string{"t1"},
+	}
+
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		if p := r.URL.Query(); !reflect.DeepEqual(p, wantParams) {
+			t.Errorf("DescribeEvents got params %# v, want %# v", pretty.Formatter(p), pretty.Formatter(wantParams))
+		}
+		writeJSON(w, `
+{
+    "DescribeEventsResponse": {"DescribeEventsResult": {
+        "Events": [
+            {
+                "ApplicationName": "app",
+                "EnvironmentName": "env",
+                "EventDate": `+floatTime(t, "2014-02-28T00:33:47.684Z")+`,
+                "Message": "Environment update completed successfully.",
+                "Severity": "INFO",
+                "VersionLabel": "v1"
+            }
+        ],
+        "NextToken": "t2"
+    }}
+}
+`)
+	})
+
+	want := &DescribeEventsResult{
+		Events: []*EventDescription{
+			{
+				ApplicationName: "app",
+				EnvironmentName: "env",
+				EventDate:       mustParseTime(t, "2014-02-28T00:33:47.684Z"),
+				Message:         "Environment update completed successfully.",
+				Severity:        "INFO",
+				VersionLabel:    "v1",
+			},
+		},
+		NextToken: "t2",
+	}
+
+	got, err := client.DescribeEvents(&DescribeEventsParams{
+		EnvironmentName: "env",
+		StartTime:       time.Date(2014, 2, 28, 0, 0, 0, 0, time.UTC),
+		MaxRecords:      2,
+		NextToken:       "t1",
+	})
+	if err != nil {
+		t.Fatalf("DescribeEvents returned error: %v", err)
+	}
+	normTime(&want.Events[0].EventDate)
+	normTime(&got.Events[0].EventDate)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DescribeEvents returned %+v, want %+v", asJSON(t, got), asJSON(t, want))
+	}
+}
+
+func TestEventPoller(t *testing.T) {
+	setup()
+	defer teardown()
+
+	t0 := time.Date(2014, 2, 28, 0, 0, 0, 0, time.UTC)
+	var events []*EventDescription // oldest first
+	addEvent := func(sec int, msg string) {
+		events = append(events, &EventDescription{EventDate: Time{t0.Add(time.Duration(sec) * time.Second)}, Message: msg})
+	}
+
+	// Serve the events newest first, 2 per page, from StartTime on.
+	var startTimes []string
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		var matching []*EventDescription
+		for i := len(events) - 1; i >= 0; i-- {
+			if start := q.Get("StartTime"); start == "" || events[i].EventDate.Format(time.RFC3339) >= start {
+				matching = append(matching, events[i])
+			}
+		}
+		if q.Get("NextToken") == "" {
+			startTimes = append(startTimes, q.Get("StartTime"))
+		}
+		var res DescribeEventsResult
+		if q.Get("NextToken") == "" && len(matching) > 2 {
+			res.Events, res.NextToken = matching[:2], "2"
+		} else if q.Get("NextToken") == "2" {
+			res.Events = matching[2:]
+		} else {
+			res.Events = matching
+		}
+		writeJSON(w, `{"DescribeEventsResponse":{"DescribeEventsResult":`+asJSON(t, res)+`}}`)
+	})
+
+	messages := func(evs []*EventDescription) []string {
+		var msgs []string
+		for _, ev := range evs {
+			msgs = append(msgs, ev.Message)
+		}
+		return msgs
+	}
+
+	p := &EventPoller{API: client, Params: DescribeEventsParams{EnvironmentName: "env"}}
+	addEvent(0, "a")
+	addEvent(1, "b")
+	addEvent(1, "c")
+	evs, err := p.Poll()
+	if err != nil {
+		t.Fatalf("Poll returned error: %v", err)
+	}
+	if got, want := messages(evs), []string{"a", "b", "c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("first Poll: got %v, want %v", got, want)
+	}
+
+	// Events at the same time as the last one returned must be returned
+	// once, even though DescribeEvents returns them again.
+	addEvent(1, "d")
+	addEvent(2, "e")
+	evs, err = p.Poll()
+	if err != nil {
+		t.Fatalf("Poll returned error: %v", err)
+	}
+	if got, want := messages(evs), []string{"d", "e"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("second Poll: got %v, want %v", got, want)
+	}
+
+	evs, err = p.Poll()
+	if err != nil {
+		t.Fatalf("Poll returned error: %v", err)
+	}
+	if len(evs) != 0 {
+		t.Errorf("third Poll: got %v, want none", messages(evs))
+	}
+
+	if want := []string{"", "2014-02-28T00:00:01Z", "2014-02-28T00:00:02Z"}; !reflect.DeepEqual(startTimes, want) {
+		t.Errorf("got StartTimes %v, want %v", startTimes, want)
+	}
+}
+
+// eventsAPI is an API whose DescribeEvents returns the events (newest first)
+// that are not before the StartTime param, truncated to the second as it is
+// when sent.
+type eventsAPI struct {
+	API
+	events []*EventDescription
+}
+
+func (a eventsAPI) DescribeEvents(params *DescribeEventsParams) (*DescribeEventsResult, error) {
+	start := params.StartTime.Truncate(time.Second)
+	var res DescribeEventsResult
+	for _, ev := range a.events {
+		if !ev.EventDate.Before(start) {
+			res.Events = append(res.Events, ev)
+		}
+	}
+	return &res, nil
+}
+
+func TestEventPoller_sameSecond(t *testing.T) {
+	t0 := time.Date(2014, 2, 28, 0, 0, 10, 0, time.UTC)
+	api := eventsAPI{events: []*EventDescription{
+		{EventDate: Time{t0.Add(700 * time.Millisecond)}, Message: "b"},
+		{EventDate: Time{t0.Add(200 * time.Millisecond)}, Message: "a"},
+	}}
+
+	p := &EventPoller{API: api, Params: DescribeEventsParams{EnvironmentName: "env"}}
+	evs, err := p.Poll()
+	if err != nil {
+		t.Fatalf("Poll returned error: %v", err)
+	}
+	if len(evs) != 2 {
+		t.Fatalf("first Poll: got %d events, want 2", len(evs))
+	}
+
+	// Both events are returned again, because they're in the same second as
+	// the new StartTime, but neither is new.
+	evs, err = p.Poll()
+	if err != nil {
+		t.Fatalf("Poll returned error: %v", err)
+	}
+	if len(evs) != 0 {
+		t.Errorf("second Poll: got %d events (first %q), want none", len(evs), evs[0].Message)
+	}
+}
+
+// nilEventsAPI is an API whose DescribeEvents returns a nil result and a nil
+// error.
+type nilEventsAPI struct{ API }
+
+func (nilEventsAPI) DescribeEvents(*DescribeEventsParams) (*DescribeEventsResult, error) {
+	return nil, nil
+}
+
+func TestEventPoller_nilResult(t *testing.T) {
+	p := &EventPoller{API: nilEventsAPI{}}
+	evs, err := p.Poll()
+	if err != nil {
+		t.Fatalf("Poll returned error: %v", err)
+	}
+	if len(evs) != 0 {
+		t.Errorf("got %d events, want none", len(evs))
+	}
+}
+
+func TestVersionHistory(t *testing.T) {
+	setup()
+	defer teardown()
+
+	// Served newest first, in 2 pages.
+	pages := map[string]DescribeEventsResult{
+		"": {
+			Events: []*EventDescription{
+				{VersionLabel: "v3", Message: "Environment update is starting."},
+				{VersionLabel: "v2", Message: "Environment update completed successfully."},
+				{VersionLabel: "v2", Message: "Environment update is starting."},
+			},
+			NextToken: "2",
+		},
+		"2": {
+			Events: []*EventDescription{
+				{VersionLabel: "v1", Message: "Environment update completed successfully."},
+				{VersionLabel: "v2", Message: "Environment update completed successfully."},
+				{Message: "Environment health has transitioned from Ok to Info."},
+				{VersionLabel: "v0", Message: "Successfully launched environment: env"},
+			},
+		},
+	}
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		if got := r.URL.Query().Get("EnvironmentName"); got != "env" {
+			t.Errorf("got EnvironmentName %q, want env", got)
+		}
+		res := pages[r.URL.Query().Get("NextToken")]
+		writeJSON(w, `{"DescribeEventsResponse": {"DescribeEventsResult": `+asJSON(t, res)+`}}`)
+	})
+
+	labels, err := VersionHistory(client, DescribeEventsParams{EnvironmentName: "env"})
+	if err != nil {
+		t.Fatalf("VersionHistory returned error: %v", err)
+	}
+	// v3 was never successfully deployed, and v2 appears at its most recent
+	// deployment.
+	if want := []string{"v2", "v1", "v0"}; !reflect.DeepEqual(labels, want) {
+		t.Errorf("got labels %v, want %v", labels, want)
+	}
+}
+
+func TestVersionHistory_nilResult(t *testing.T) {
+	labels, err := VersionHistory(nilEventsAPI{}, DescribeEventsParams{EnvironmentName: "env"})
+	if err != nil {
+		t.Fatalf("VersionHistory returned error: %v", err)
+	}
+	if len(labels) != 0 {
+		t.Errorf("got labels %v, want none", labels)
+	}
+}
@@ -0,0 +1,89 @@
+package elasticbeanstalk
+
+import (
+	"fmt"
+
+	"github.com/google/go-querystring/query"
+)
+
+// DescribeEnvironmentHealthParams specifies parameters for
+// DescribeEnvironmentHealth.
+//
+// See
+// http://docs.aws.amazon.com/elasticbeanstalk/latest/api/API_DescribeEnvironmentHealth.html.
+type DescribeEnvironmentHealthParams struct {
+	EnvironmentName string `url:",omitempty"`
+	EnvironmentId   string `url:",omitempty"`
+
+	// AttributeNames are the health attributes to return (such as "Color"
+	// and "Causes"). If empty, all attributes are returned.
+	AttributeNames []string `url:"-"`
+}
+
+// EnvironmentHealth describes the health of an environment. It is only
+// available for environments with enhanced health reporting enabled.
+//
+// See
+// http://docs.aws.amazon.com/elasticbeanstalk/latest/api/API_DescribeEnvironmentHealth.html.
+type EnvironmentHealth struct {
+	EnvironmentName string
+
+	// HealthStatus is the health status (such as "Ok", "Warning", or
+	// "Severe"), and Color is its color (such as "Green").
+	HealthStatus string
+	Color        string
+
+	// Status is the environment's operational status (such as "Ready").
+	Status string
+
+	// Causes are descriptions of the reasons for the health status.
+	Causes []string
+
+	InstancesHealth InstanceHealthSummary
+	RefreshedAt     Time
+
+	// Omitted fields: ApplicationMetrics
+}
+
+// InstanceHealthSummary is the number of instances in an environment with
+// each health status.
+//
+// See
+// http://docs.aws.amazon.com/elasticbeanstalk/latest/api/API_InstanceHealthSummary.html.
+type InstanceHealthSummary struct {
+	NoData   int
+	Unknown  int
+	Pending  int
+	Ok       int
+	Info     int
+	Warning  int
+	Degraded int
+	Severe   int
+}
+
+// DescribeEnvironmentHealth returns the health of an environment.
+//
+// See
+// http://docs.aws.amazon.com/elasticbeanstalk/latest/api/API_DescribeEnvironmentHealth.html.
+func (c *Client) DescribeEnvironmentHealth(params *DescribeEnvironmentHealthParams) (*EnvironmentHealth, error) {
+	v, err := query.Values(params)
+	if err != nil {
+		return nil, err
+	}
+	attrs := params.AttributeNames
+	if len(attrs) == 0 {
+		attrs = []string{"All"}
+	}
+	for i, a := range attrs {
+		v.Set(fmt.Sprintf("AttributeNames.member.%d", i+1), a)
+	}
+	var o struct {
+		DescribeEnvironmentHealthResponse struct {
+			DescribeEnvironmentHealthResult EnvironmentHealth
+		}
+	}
+	if err := c.Do("GET", "DescribeEnvironmentHealth", v, &o); err != nil {
+		return nil, err
+	}
+	return &o.DescribeEnvironmentHealthResponse.DescribeEnvironmentHealthResult, nil
+}
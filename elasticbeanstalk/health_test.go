@@ -0,0 +1,57 @@
+package elasticbeanstalk
+
+import (
+	"net/http"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/kr/pretty"
+)
+
+func TestDescribeEnvironmentHealth(t *testing.T) {
+	setup()
+	defer teardown()
+
+	wantParams := url.Values{
+		"Operation":               []string{"DescribeEnvironmentHealth"},
+		"EnvironmentName":         []string{"env"},
+		"AttributeNames.member.1": []string{"All"},
+	}
+
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		if p := r.URL.Query(); !reflect.DeepEqual(p, wantParams) {
+			t.Errorf("DescribeEnvironmentHealth got params %# v, want %# v", pretty.Formatter(p), pretty.Formatter(wantParams))
+		}
+		writeJSON(w, `
+{
+    "DescribeEnvironmentHealthResponse": {"DescribeEnvironmentHealthResult": {
+        "EnvironmentName": "env",
+        "HealthStatus": "Degraded",
+        "Color": "Red",
+        "Status": "Ready",
+        "Causes": ["50% of requests are failing with HTTP 5xx."],
+        "InstancesHealth": {"Ok": 1, "Degraded": 1}
+    }}
+}
+`)
+	})
+
+	want := &EnvironmentHealth{
+		EnvironmentName: "env",
+		HealthStatus:    "Degraded",
+		Color:           "Red",
+		Status:          "Ready",
+		Causes:          []string{"50% of requests are failing with HTTP 5xx."},
+		InstancesHealth: InstanceHealthSummary{Ok: 1, Degraded: 1},
+	}
+
+	got, err := client.DescribeEnvironmentHealth(&DescribeEnvironmentHealthParams{EnvironmentName: "env"})
+	if err != nil {
+		t.Fatalf("DescribeEnvironmentHealth returned error: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DescribeEnvironmentHealth returned %+v, want %+v", asJSON(t, got), asJSON(t, want))
+	}
+}
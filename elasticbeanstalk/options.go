@@ -0,0 +1,290 @@
+package elasticbeanstalk
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Namespaces of commonly used configuration options.
+//
+// See
+// http://docs.aws.amazon.com/elasticbeanstalk/latest/dg/command-options-general.html.
+const (
+	NamespaceApplicationEnvironment = "aws:elasticbeanstalk:application:environment"
+	NamespaceAutoScalingGroup       = "aws:autoscaling:asg"
+	NamespaceLaunchConfiguration    = "aws:autoscaling:launchconfiguration"
+	NamespaceELBListener            = "aws:elb:listener"
+	NamespaceALBListener            = "aws:elbv2:listener"
+	NamespaceVPC                    = "aws:ec2:vpc"
+	NamespaceCommand                = "aws:elasticbeanstalk:command"
+	NamespaceHealthReporting        = "aws:elasticbeanstalk:healthreporting:system"
+	NamespaceLogStreaming           = "aws:elasticbeanstalk:cloudwatch:logs"
+)
+
+// An OptionSettingsBuilder produces configuration option settings. The
+// builders in this package (such as AutoScalingGroupOptions) only produce
+// settings for fields that are set; nil pointer, empty string, and empty
+// slice fields are omitted.
+type OptionSettingsBuilder interface {
+	OptionSettings() ConfigurationOptionSettings
+}
+
+// AddOptions appends the option settings produced by each builder to
+// OptionSettings.
+func (p *UpdateEnvironmentParams) AddOptions(builders ...OptionSettingsBuilder) {
+	for _, b := range builders {
+		p.OptionSettings = append(p.OptionSettings, b.OptionSettings()...)
+	}
+}
+
+// Bool returns a pointer to v, for use in option builder fields.
+func Bool(v bool) *bool { return &v }
+
+// Int returns a pointer to v, for use in option builder fields.
+func Int(v int) *int { return &v }
+
+// Duration returns a pointer to v, for use in option builder fields.
+func Duration(v time.Duration) *time.Duration { return &v }
+
+// optionSettings accumulates the settings for a single namespace, skipping
+// unset values.
+type optionSettings struct {
+	namespace string
+	opts      ConfigurationOptionSettings
+}
+
+func (o *optionSettings) string(name, v string) {
+	if v != "" {
+		o.opts = append(o.opts, ConfigurationOptionSetting{Namespace: o.namespace, OptionName: name, Value: v})
+	}
+}
+
+func (o *optionSettings) bool(name string, v *bool) {
+	if v != nil {
+		o.string(name, strconv.FormatBool(*v))
+	}
+}
+
+func (o *optionSettings) int(name string, v *int) {
+	if v != nil {
+		o.string(name, strconv.Itoa(*v))
+	}
+}
+
+// seconds adds a duration option whose value is a whole number of seconds.
+func (o *optionSettings) seconds(name string, v *time.Duration) {
+	if v != nil {
+		o.string(name, strconv.FormatInt(int64(v.Round(time.Second)/time.Second), 10))
+	}
+}
+
+// list adds an option whose value is a comma-separated list.
+func (o *optionSettings) list(name string, v []string) {
+	if len(v) > 0 {
+		o.string(name, strings.Join(v, ","))
+	}
+}
+
+// listenerNamespace returns the namespace for the load balancer listener on
+// port, or the default listener's namespace if port is zero.
+func listenerNamespace(namespace string, port int, defaultName string) string {
+	if port == 0 {
+		if defaultName == "" {
+			return namespace
+		}
+		return namespace + ":" + defaultName
+	}
+	return fmt.Sprintf("%s:%d", namespace, port)
+}
+
+// AutoScalingGroupOptions configures the environment's Auto Scaling group
+// (the aws:autoscaling:asg namespace).
+type AutoScalingGroupOptions struct {
+	MinSize  *int
+	MaxSize  *int
+	Cooldown *time.Duration
+
+	// AvailabilityZones is "Any", "Any 1", "Any 2", or "Any 3".
+	AvailabilityZones       string
+	CustomAvailabilityZones []string
+}
+
+func (a *AutoScalingGroupOptions) OptionSettings() ConfigurationOptionSettings {
+	o := &optionSettings{namespace: NamespaceAutoScalingGroup}
+	o.int("MinSize", a.MinSize)
+	o.int("MaxSize", a.MaxSize)
+	o.seconds("Cooldown", a.Cooldown)
+	o.string("Availability Zones", a.AvailabilityZones)
+	o.list("Custom Availability Zones", a.CustomAvailabilityZones)
+	return o.opts
+}
+
+// LaunchConfigurationOptions configures the EC2 instances of the environment
+// (the aws:autoscaling:launchconfiguration namespace).
+type LaunchConfigurationOptions struct {
+	InstanceType       string
+	EC2KeyName         string
+	IamInstanceProfile string
+	ImageId            string
+	SecurityGroups     []string
+
+	// MonitoringInterval is 1 or 5 minutes.
+	MonitoringInterval *time.Duration
+
+	RootVolumeType string
+	RootVolumeSize *int // in GB
+	DisableIMDSv1  *bool
+}
+
+func (l *LaunchConfigurationOptions) OptionSettings() ConfigurationOptionSettings {
+	o := &optionSettings{namespace: NamespaceLaunchConfiguration}
+	o.string("InstanceType", l.InstanceType)
+	o.string("EC2KeyName", l.EC2KeyName)
+	o.string("IamInstanceProfile", l.IamInstanceProfile)
+	o.string("ImageId", l.ImageId)
+	o.list("SecurityGroups", l.SecurityGroups)
+	if l.MonitoringInterval != nil {
+		o.string("MonitoringInterval", fmt.Sprintf("%d minute", int(l.MonitoringInterval.Minutes())))
+	}
+	o.string("RootVolumeType", l.RootVolumeType)
+	o.int("RootVolumeSize", l.RootVolumeSize)
+	o.bool("DisableIMDSv1", l.DisableIMDSv1)
+	return o.opts
+}
+
+// ELBListenerOptions configures a listener of the environment's Classic Load
+// Balancer (the aws:elb:listener namespaces).
+type ELBListenerOptions struct {
+	// Port is the listener port. If zero, the default listener (on port 80)
+	// is configured.
+	Port int
+
+	ListenerProtocol string
+	InstancePort     *int
+	InstanceProtocol string
+	SSLCertificateId string
+	ListenerEnabled  *bool
+}
+
+func (l *ELBListenerOptions) OptionSettings() ConfigurationOptionSettings {
+	o := &optionSettings{namespace: listenerNamespace(NamespaceELBListener, l.Port, "")}
+	o.string("ListenerProtocol", l.ListenerProtocol)
+	o.int("InstancePort", l.InstancePort)
+	o.string("InstanceProtocol", l.InstanceProtocol)
+	o.string("SSLCertificateId", l.SSLCertificateId)
+	o.bool("ListenerEnabled", l.ListenerEnabled)
+	return o.opts
+}
+
+// ALBListenerOptions configures a listener of the environment's Application
+// Load Balancer (the aws:elbv2:listener namespaces).
+type ALBListenerOptions struct {
+	// Port is the listener port. If zero, the default listener is
+	// configured.
+	Port int
+
+	Protocol          string
+	DefaultProcess    string
+	ListenerEnabled   *bool
+	SSLCertificateArn string
+	SSLPolicy         string
+	Rules             []string
+}
+
+func (l *ALBListenerOptions) OptionSettings() ConfigurationOptionSettings {
+	o := &optionSettings{namespace: listenerNamespace(NamespaceALBListener, l.Port, "default")}
+	o.string("Protocol", l.Protocol)
+	o.string("DefaultProcess", l.DefaultProcess)
+	o.bool("ListenerEnabled", l.ListenerEnabled)
+	o.string("SSLCertificateArns", l.SSLCertificateArn)
+	o.string("SSLPolicy", l.SSLPolicy)
+	o.list("Rules", l.Rules)
+	return o.opts
+}
+
+// VPCOptions places the environment in a VPC (the aws:ec2:vpc namespace).
+type VPCOptions struct {
+	VPCId      string
+	Subnets    []string
+	ELBSubnets []string
+
+	// ELBScheme is "public" or "internal".
+	ELBScheme string
+
+	AssociatePublicIpAddress *bool
+}
+
+func (v *VPCOptions) OptionSettings() ConfigurationOptionSettings {
+	o := &optionSettings{namespace: NamespaceVPC}
+	o.string("VPCId", v.VPCId)
+	o.list("Subnets", v.Subnets)
+	o.list("ELBSubnets", v.ELBSubnets)
+	o.string("ELBScheme", v.ELBScheme)
+	o.bool("AssociatePublicIpAddress", v.AssociatePublicIpAddress)
+	return o.opts
+}
+
+// CommandOptions configures how application versions are deployed (the
+// aws:elasticbeanstalk:command namespace).
+type CommandOptions struct {
+	// DeploymentPolicy is "AllAtOnce", "Rolling",
+	// "RollingWithAdditionalBatch", "Immutable", or "TrafficSplitting".
+	DeploymentPolicy string
+
+	Timeout *time.Duration
+
+	// BatchSizeType is "Percentage" or "Fixed".
+	BatchSizeType string
+	BatchSize     *int
+
+	IgnoreHealthCheck *bool
+}
+
+func (c *CommandOptions) OptionSettings() ConfigurationOptionSettings {
+	o := &optionSettings{namespace: NamespaceCommand}
+	o.string("DeploymentPolicy", c.DeploymentPolicy)
+	o.seconds("Timeout", c.Timeout)
+	o.string("BatchSizeType", c.BatchSizeType)
+	o.int("BatchSize", c.BatchSize)
+	o.bool("IgnoreHealthCheck", c.IgnoreHealthCheck)
+	return o.opts
+}
+
+// HealthReportingOptions configures health reporting (the
+// aws:elasticbeanstalk:healthreporting:system namespace).
+type HealthReportingOptions struct {
+	// SystemType is "basic" or "enhanced".
+	SystemType string
+
+	EnhancedHealthAuthEnabled *bool
+
+	// HealthCheckSuccessThreshold is "Ok", "Warning", "Degraded", or
+	// "Severe".
+	HealthCheckSuccessThreshold string
+}
+
+func (h *HealthReportingOptions) OptionSettings() ConfigurationOptionSettings {
+	o := &optionSettings{namespace: NamespaceHealthReporting}
+	o.string("SystemType", h.SystemType)
+	o.bool("EnhancedHealthAuthEnabled", h.EnhancedHealthAuthEnabled)
+	o.string("HealthCheckSuccessThreshold", h.HealthCheckSuccessThreshold)
+	return o.opts
+}
+
+// LogStreamingOptions configures streaming of instance logs to CloudWatch
+// Logs (the aws:elasticbeanstalk:cloudwatch:logs namespace).
+type LogStreamingOptions struct {
+	StreamLogs        *bool
+	DeleteOnTerminate *bool
+	RetentionInDays   *int
+}
+
+func (l *LogStreamingOptions) OptionSettings() ConfigurationOptionSettings {
+	o := &optionSettings{namespace: NamespaceLogStreaming}
+	o.bool("StreamLogs", l.StreamLogs)
+	o.bool("DeleteOnTerminate", l.DeleteOnTerminate)
+	o.int("RetentionInDays", l.RetentionInDays)
+	return o.opts
+}
@@ -0,0 +1,114 @@
+package elasticbeanstalk
+
+import (
+	"net/http"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/kr/pretty"
+)
+
+func TestOptionSettingsBuilders(t *testing.T) {
+	tests := []struct {
+		builder OptionSettingsBuilder
+		want    ConfigurationOptionSettings
+	}{
+		{
+			builder: &AutoScalingGroupOptions{MinSize: Int(1), MaxSize: Int(4), Cooldown: Duration(6 * time.Minute), CustomAvailabilityZones: []string{"us-west-2a", "us-west-2b"}},
+			want: ConfigurationOptionSettings{
+				{Namespace: "aws:autoscaling:asg", OptionName: "MinSize", Value: "1"},
+				{Namespace: "aws:autoscaling:asg", OptionName: "MaxSize", Value: "4"},
+				{Namespace: "aws:autoscaling:asg", OptionName: "Cooldown", Value: "360"},
+				{Namespace: "aws:autoscaling:asg", OptionName: "Custom Availability Zones", Value: "us-west-2a,us-west-2b"},
+			},
+		},
+		{
+			builder: &LaunchConfigurationOptions{InstanceType: "t3.micro", MonitoringInterval: Duration(time.Minute), DisableIMDSv1: Bool(false)},
+			want: ConfigurationOptionSettings{
+				{Namespace: "aws:autoscaling:launchconfiguration", OptionName: "InstanceType", Value: "t3.micro"},
+				{Namespace: "aws:autoscaling:launchconfiguration", OptionName: "MonitoringInterval", Value: "1 minute"},
+				{Namespace: "aws:autoscaling:launchconfiguration", OptionName: "DisableIMDSv1", Value: "false"},
+			},
+		},
+		{
+			builder: &ELBListenerOptions{ListenerEnabled: Bool(false)},
+			want: ConfigurationOptionSettings{
+				{Namespace: "aws:elb:listener", OptionName: "ListenerEnabled", Value: "false"},
+			},
+		},
+		{
+			builder: &ALBListenerOptions{Port: 443, Protocol: "HTTPS", Rules: []string{"a", "b"}},
+			want: ConfigurationOptionSettings{
+				{Namespace: "aws:elbv2:listener:443", OptionName: "Protocol", Value: "HTTPS"},
+				{Namespace: "aws:elbv2:listener:443", OptionName: "Rules", Value: "a,b"},
+			},
+		},
+		{
+			builder: &ALBListenerOptions{ListenerEnabled: Bool(true)},
+			want: ConfigurationOptionSettings{
+				{Namespace: "aws:elbv2:listener:default", OptionName: "ListenerEnabled", Value: "true"},
+			},
+		},
+		{
+			builder: &CommandOptions{DeploymentPolicy: "Rolling", Timeout: Duration(10 * time.Minute), BatchSize: Int(30)},
+			want: ConfigurationOptionSettings{
+				{Namespace: "aws:elasticbeanstalk:command", OptionName: "DeploymentPolicy", Value: "Rolling"},
+				{Namespace: "aws:elasticbeanstalk:command", OptionName: "Timeout", Value: "600"},
+				{Namespace: "aws:elasticbeanstalk:command", OptionName: "BatchSize", Value: "30"},
+			},
+		},
+		{
+			builder: &LogStreamingOptions{StreamLogs: Bool(true), RetentionInDays: Int(7)},
+			want: ConfigurationOptionSettings{
+				{Namespace: "aws:elasticbeanstalk:cloudwatch:logs", OptionName: "StreamLogs", Value: "true"},
+				{Namespace: "aws:elasticbeanstalk:cloudwatch:logs", OptionName: "RetentionInDays", Value: "7"},
+			},
+		},
+		{
+			builder: &VPCOptions{},
+			want:    nil,
+		},
+	}
+	for _, test := range tests {
+		if got := test.builder.OptionSettings(); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%T: got %# v, want %# v", test.builder, pretty.Formatter(got), pretty.Formatter(test.want))
+		}
+	}
+}
+
+func TestUpdateEnvironment_OptionSettings_ResourceName(t *testing.T) {
+	setup()
+	defer teardown()
+
+	wantParams := url.Values{
+		"Operation":                            []string{"UpdateEnvironment"},
+		"EnvironmentName":                      []string{"env"},
+		"OptionSettings.member.1.Namespace":    []string{"aws:autoscaling:asg"},
+		"OptionSettings.member.1.OptionName":   []string{"MinSize"},
+		"OptionSettings.member.1.Value":        []string{"2"},
+		"OptionSettings.member.2.Namespace":    []string{"aws:autoscaling:scheduledaction"},
+		"OptionSettings.member.2.OptionName":   []string{"MinSize"},
+		"OptionSettings.member.2.ResourceName": []string{"ScheduledScaleUp"},
+		"OptionSettings.member.2.Value":        []string{"4"},
+	}
+
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if p := r.URL.Query(); !reflect.DeepEqual(p, wantParams) {
+			t.Errorf("UpdateEnvironment got params %# v, want %# v", pretty.Formatter(p), pretty.Formatter(wantParams))
+		}
+	})
+
+	p := &UpdateEnvironmentParams{EnvironmentName: "env"}
+	p.AddOptions(&AutoScalingGroupOptions{MinSize: Int(2)})
+	p.OptionSettings = append(p.OptionSettings, ConfigurationOptionSetting{
+		Namespace:    "aws:autoscaling:scheduledaction",
+		OptionName:   "MinSize",
+		ResourceName: "ScheduledScaleUp",
+		Value:        "4",
+	})
+	if err := client.UpdateEnvironment(p); err != nil {
+		t.Errorf("UpdateEnvironment returned error: %v", err)
+	}
+}
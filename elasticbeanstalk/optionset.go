@@ -0,0 +1,183 @@
+package elasticbeanstalk
+
+import "sort"
+
+// An OptionKey identifies a configuration option by its namespace, resource
+// name (which is empty for most options), and option name.
+type OptionKey struct {
+	Namespace    string
+	ResourceName string
+	OptionName   string
+}
+
+func (k OptionKey) String() string {
+	if k.ResourceName != "" {
+		return k.Namespace + "(" + k.ResourceName + ")." + k.OptionName
+	}
+	return k.Namespace + "." + k.OptionName
+}
+
+// EnvKey returns the key of the environment variable named name.
+func EnvKey(name string) OptionKey {
+	return OptionKey{Namespace: envVarNamespace, OptionName: name}
+}
+
+// Key returns the key that identifies the option.
+func (s ConfigurationOptionSetting) Key() OptionKey {
+	return OptionKey{Namespace: s.Namespace, ResourceName: s.ResourceName, OptionName: s.OptionName}
+}
+
+// Get returns the value of the option identified by key. If opts contains
+// the option more than once, the last value is returned.
+func (opts ConfigurationOptionSettings) Get(key OptionKey) (value string, ok bool) {
+	for i := len(opts) - 1; i >= 0; i-- {
+		if opts[i].Key() == key {
+			return opts[i].Value, true
+		}
+	}
+	return "", false
+}
+
+// Set sets the value of the option identified by key, replacing any
+// existing values for it.
+func (opts *ConfigurationOptionSettings) Set(key OptionKey, value string) {
+	opts.Remove(key)
+	*opts = append(*opts, ConfigurationOptionSetting{
+		Namespace:    key.Namespace,
+		ResourceName: key.ResourceName,
+		OptionName:   key.OptionName,
+		Value:        value,
+	})
+}
+
+// Remove removes all values of the option identified by key. It reports
+// whether any were removed.
+func (opts *ConfigurationOptionSettings) Remove(key OptionKey) bool {
+	kept := (*opts)[:0]
+	for _, o := range *opts {
+		if o.Key() != key {
+			kept = append(kept, o)
+		}
+	}
+	removed := len(kept) < len(*opts)
+	*opts = kept
+	return removed
+}
+
+// Redacted returns a copy of opts in which the values of environment
+// variables are replaced with "REDACTED" (as in
+// (*Operation).RedactedParams), for displaying the options.
+func (opts ConfigurationOptionSettings) Redacted() ConfigurationOptionSettings {
+	r := make(ConfigurationOptionSettings, len(opts))
+	for i, o := range opts {
+		if o.Namespace == envVarNamespace {
+			o.Value = "REDACTED"
+		}
+		r[i] = o
+	}
+	return r
+}
+
+// Index returns a map of each option's key to its value. If opts contains
+// an option more than once, the last value is used.
+func (opts ConfigurationOptionSettings) Index() map[OptionKey]string {
+	m := make(map[OptionKey]string, len(opts))
+	for _, o := range opts {
+		m[o.Key()] = o.Value
+	}
+	return m
+}
+
+// A Precedence determines which value is kept when merged option settings
+// both set the same option.
+type Precedence int
+
+const (
+	// Overwrite keeps the value from the settings being merged in.
+	Overwrite Precedence = iota
+
+	// KeepExisting keeps the value from the settings being merged into.
+	KeepExisting
+)
+
+// Merge returns a new ConfigurationOptionSettings containing the options
+// in opts and other. When both set the same option, prec determines which
+// value is kept. The result contains each option at most once, in the order
+// it first appears in opts and then other.
+func (opts ConfigurationOptionSettings) Merge(other ConfigurationOptionSettings, prec Precedence) ConfigurationOptionSettings {
+	merged := make(ConfigurationOptionSettings, 0, len(opts)+len(other))
+	pos := map[OptionKey]int{}
+	add := func(o ConfigurationOptionSetting, overwrite bool) {
+		if i, present := pos[o.Key()]; present {
+			if overwrite {
+				merged[i].Value = o.Value
+			}
+			return
+		}
+		pos[o.Key()] = len(merged)
+		merged = append(merged, o)
+	}
+	for _, o := range opts {
+		add(o, true)
+	}
+	for _, o := range other {
+		add(o, prec == Overwrite)
+	}
+	return merged
+}
+
+// A ChangeKind describes how an option differs between two sets of option
+// settings.
+type ChangeKind string
+
+const (
+	Added   ChangeKind = "added"
+	Removed ChangeKind = "removed"
+	Changed ChangeKind = "changed"
+)
+
+// An OptionChange describes an option whose value differs between two sets
+// of option settings. Old is empty for added options, and New is empty for
+// removed options.
+type OptionChange struct {
+	Key      OptionKey
+	Kind     ChangeKind
+	Old, New string
+}
+
+// Diff returns the changes that turn opts into to, ordered by key.
+func (opts ConfigurationOptionSettings) Diff(to ConfigurationOptionSettings) []OptionChange {
+	from, toIndex := opts.Index(), to.Index()
+	var changes []OptionChange
+	for k, old := range from {
+		if v, present := toIndex[k]; !present {
+			changes = append(changes, OptionChange{Key: k, Kind: Removed, Old: old})
+		} else if v != old {
+			changes = append(changes, OptionChange{Key: k, Kind: Changed, Old: old, New: v})
+		}
+	}
+	for k, v := range toIndex {
+		if _, present := from[k]; !present {
+			changes = append(changes, OptionChange{Key: k, Kind: Added, New: v})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Key.String() < changes[j].Key.String() })
+	return changes
+}
+
+// Apply returns the option settings that an environment whose current
+// option settings are current would have after the update: current, without
+// the options in OptionsToRemove, merged with OptionSettings.
+func (p *UpdateEnvironmentParams) Apply(current ConfigurationOptionSettings) ConfigurationOptionSettings {
+	updated := current.Merge(nil, Overwrite)
+	for _, o := range p.OptionsToRemove {
+		updated.Remove(o.Key())
+	}
+	return updated.Merge(p.OptionSettings, Overwrite)
+}
+
+// Changes returns the changes that the update would make to an environment
+// whose current option settings are current (see Apply).
+func (p *UpdateEnvironmentParams) Changes(current ConfigurationOptionSettings) []OptionChange {
+	return current.Diff(p.Apply(current))
+}
@@ -0,0 +1,155 @@
+package elasticbeanstalk
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kr/pretty"
+)
+
+func TestConfigurationOptionSettings_GetSetRemove(t *testing.T) {
+	k := OptionKey{Namespace: "aws:autoscaling:asg", OptionName: "MinSize"}
+	rk := OptionKey{Namespace: "aws:autoscaling:scheduledaction", ResourceName: "r", OptionName: "MinSize"}
+
+	var opts ConfigurationOptionSettings
+	opts.Set(k, "1")
+	opts.Set(rk, "2")
+	opts.Set(k, "3")
+	if v, ok := opts.Get(k); !ok || v != "3" {
+		t.Errorf("Get(%v) = %q, %v, want %q, true", k, v, ok, "3")
+	}
+	if v, ok := opts.Get(rk); !ok || v != "2" {
+		t.Errorf("Get(%v) = %q, %v, want %q, true", rk, v, ok, "2")
+	}
+	if len(opts) != 2 {
+		t.Errorf("got %d settings, want 2", len(opts))
+	}
+
+	if !opts.Remove(k) {
+		t.Errorf("Remove(%v) = false, want true", k)
+	}
+	if _, ok := opts.Get(k); ok {
+		t.Errorf("Get(%v) after Remove found a value", k)
+	}
+	if opts.Remove(k) {
+		t.Errorf("second Remove(%v) = true, want false", k)
+	}
+}
+
+func TestConfigurationOptionSettings_Redacted(t *testing.T) {
+	opts := ConfigurationOptionSettings{
+		{Namespace: NamespaceApplicationEnvironment, OptionName: "SECRET", Value: "s3cret"},
+		{Namespace: NamespaceAutoScalingGroup, OptionName: "MinSize", Value: "2"},
+	}
+	want := ConfigurationOptionSettings{
+		{Namespace: NamespaceApplicationEnvironment, OptionName: "SECRET", Value: "REDACTED"},
+		{Namespace: NamespaceAutoScalingGroup, OptionName: "MinSize", Value: "2"},
+	}
+	if got := opts.Redacted(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+	if opts[0].Value != "s3cret" {
+		t.Errorf("Redacted modified opts (got %q)", opts[0].Value)
+	}
+}
+
+func TestConfigurationOptionSettings_Merge(t *testing.T) {
+	a := ConfigurationOptionSettings{
+		{Namespace: "n", OptionName: "x", Value: "a"},
+		{Namespace: "n", OptionName: "y", Value: "a"},
+	}
+	b := ConfigurationOptionSettings{
+		{Namespace: "n", OptionName: "y", Value: "b"},
+		{Namespace: "n", OptionName: "z", Value: "b"},
+	}
+
+	tests := map[Precedence]ConfigurationOptionSettings{
+		Overwrite: {
+			{Namespace: "n", OptionName: "x", Value: "a"},
+			{Namespace: "n", OptionName: "y", Value: "b"},
+			{Namespace: "n", OptionName: "z", Value: "b"},
+		},
+		KeepExisting: {
+			{Namespace: "n", OptionName: "x", Value: "a"},
+			{Namespace: "n", OptionName: "y", Value: "a"},
+			{Namespace: "n", OptionName: "z", Value: "b"},
+		},
+	}
+	for prec, want := range tests {
+		if got := a.Merge(b, prec); !reflect.DeepEqual(got, want) {
+			t.Errorf("Merge with precedence %v: got %# v, want %# v", prec, pretty.Formatter(got), pretty.Formatter(want))
+		}
+	}
+}
+
+func TestConfigurationOptionSettings_Diff(t *testing.T) {
+	from := ConfigurationOptionSettings{
+		{Namespace: "n", OptionName: "removed", Value: "1"},
+		{Namespace: "n", OptionName: "changed", Value: "1"},
+		{Namespace: "n", OptionName: "same", Value: "1"},
+	}
+	to := ConfigurationOptionSettings{
+		{Namespace: "n", OptionName: "added", Value: "2"},
+		{Namespace: "n", OptionName: "changed", Value: "2"},
+		{Namespace: "n", OptionName: "same", Value: "1"},
+	}
+	want := []OptionChange{
+		{Key: OptionKey{Namespace: "n", OptionName: "added"}, Kind: Added, New: "2"},
+		{Key: OptionKey{Namespace: "n", OptionName: "changed"}, Kind: Changed, Old: "1", New: "2"},
+		{Key: OptionKey{Namespace: "n", OptionName: "removed"}, Kind: Removed, Old: "1"},
+	}
+	if got := from.Diff(to); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %# v, want %# v", pretty.Formatter(got), pretty.Formatter(want))
+	}
+}
+
+func TestUpdateEnvironmentParams_Changes(t *testing.T) {
+	current := ConfigurationOptionSettings{
+		{Namespace: envVarNamespace, OptionName: "K0", Value: "V0"},
+		{Namespace: envVarNamespace, OptionName: "K1", Value: "V1"},
+	}
+	p := &UpdateEnvironmentParams{}
+	p.AddEnv("K1", "V1")
+	p.AddEnv("K2", "V2")
+	want := []OptionChange{
+		{Key: EnvKey("K2"), Kind: Added, New: "V2"},
+	}
+	if got := p.Changes(current); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %# v, want %# v", pretty.Formatter(got), pretty.Formatter(want))
+	}
+}
+
+func TestUpdateEnvironmentParams_Changes_remove(t *testing.T) {
+	current := ConfigurationOptionSettings{
+		{Namespace: envVarNamespace, OptionName: "K0", Value: "V0"},
+		{Namespace: envVarNamespace, OptionName: "K1", Value: "V1"},
+	}
+	p := &UpdateEnvironmentParams{}
+	p.RemoveEnv("K0")
+	p.RemoveEnv("MISSING")
+	want := []OptionChange{
+		{Key: EnvKey("K0"), Kind: Removed, Old: "V0"},
+	}
+	if got := p.Changes(current); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %# v, want %# v", pretty.Formatter(got), pretty.Formatter(want))
+	}
+	if len(current) != 2 {
+		t.Errorf("Changes modified current: %v", current)
+	}
+}
+
+func TestConfigurationSettings_OptionSettings(t *testing.T) {
+	cs := ConfigurationSettings{
+		{DeploymentStatus: "deployed", OptionSettings: ConfigurationOptionSettings{{Namespace: envVarNamespace, OptionName: "K", Value: "deployed"}}},
+		{DeploymentStatus: "pending", OptionSettings: ConfigurationOptionSettings{{Namespace: envVarNamespace, OptionName: "K", Value: "pending"}}},
+	}
+	if got, want := cs.OptionSettings(KeepExisting).Environ()["K"], "deployed"; got != want {
+		t.Errorf("with KeepExisting, got %q, want %q", got, want)
+	}
+	if got, want := cs.Environ()["K"], "pending"; got != want {
+		t.Errorf("Environ: got %q, want %q", got, want)
+	}
+	if got := cs.Deployed(); got != cs[0] {
+		t.Errorf("Deployed: got %+v, want %+v", got, cs[0])
+	}
+}
@@ -0,0 +1,129 @@
+package elasticbeanstalk
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// A Limiter is a token-bucket rate limiter for API requests. Each operation
+// draws from the default bucket unless it has its own limit (see
+// SetOperationLimit).
+//
+// The limiter adapts to throttling: each throttling error halves the rate of
+// the bucket used by the throttled operation (down to 1/16 of its
+// configured rate), and each successful request raises it again by 1/10 of
+// the configured rate until the configured rate is reached.
+//
+// A Limiter is safe for concurrent use by multiple goroutines.
+type Limiter struct {
+	mu  sync.Mutex
+	def *bucket
+	ops map[string]*bucket
+}
+
+type bucket struct {
+	limit  float64 // configured rate (tokens per second)
+	rate   float64 // current (adapted) rate
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// NewLimiter returns a Limiter that allows rate requests per second on
+// average, with bursts of up to burst requests. The rate must be positive.
+func NewLimiter(rate float64, burst int) *Limiter {
+	return &Limiter{def: newBucket(rate, burst), ops: map[string]*bucket{}}
+}
+
+func newBucket(rate float64, burst int) *bucket {
+	if burst < 1 {
+		burst = 1
+	}
+	return &bucket{limit: rate, rate: rate, burst: float64(burst), tokens: float64(burst)}
+}
+
+// SetOperationLimit gives the named operation its own bucket that allows
+// rate requests per second with bursts of up to burst requests, instead of
+// drawing from the default bucket.
+func (l *Limiter) SetOperationLimit(operation string, rate float64, burst int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.ops[operation] = newBucket(rate, burst)
+}
+
+func (l *Limiter) bucket(operation string) *bucket {
+	if b, present := l.ops[operation]; present {
+		return b
+	}
+	return l.def
+}
+
+// Rate returns the current rate, in requests per second, of the bucket used
+// by the named operation.
+func (l *Limiter) Rate(operation string) float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.bucket(operation).rate
+}
+
+// Wait blocks until a request for the named operation is allowed or ctx is
+// done. It returns ctx.Err() if ctx is done first.
+func (l *Limiter) Wait(ctx context.Context, operation string) error {
+	l.mu.Lock()
+	b := l.bucket(operation)
+	now := time.Now()
+	b.refill(now)
+	b.tokens-- // reserve a token, possibly going into debt
+	var delay time.Duration
+	if b.tokens < 0 {
+		delay = time.Duration(-b.tokens / b.rate * float64(time.Second))
+	}
+	l.mu.Unlock()
+
+	if delay == 0 {
+		return nil
+	}
+	t := time.NewTimer(delay)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		// Give back the reserved token.
+		l.mu.Lock()
+		b.tokens++
+		l.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+func (b *bucket) refill(now time.Time) {
+	if !b.last.IsZero() {
+		b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	}
+	b.last = now
+}
+
+// Throttled reports that a request for the named operation was throttled,
+// which reduces the rate of its bucket.
+func (l *Limiter) Throttled(operation string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	b := l.bucket(operation)
+	b.refill(time.Now())
+	b.rate = math.Max(b.rate/2, b.limit/16)
+}
+
+// Succeeded reports that a request for the named operation succeeded, which
+// raises the rate of its bucket if it was reduced by Throttled.
+func (l *Limiter) Succeeded(operation string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	b := l.bucket(operation)
+	if b.rate < b.limit {
+		b.refill(time.Now())
+		b.rate = math.Min(b.limit, b.rate+b.limit/10)
+	}
+}
@@ -0,0 +1,96 @@
+package elasticbeanstalk
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestLimiter_Wait(t *testing.T) {
+	start := time.Now()
+	l := NewLimiter(100, 2)
+	ctx := context.Background()
+	for i := 0; i < 4; i++ {
+		if err := l.Wait(ctx, "DescribeEnvironments"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The first 2 requests are allowed by the burst, and the next 2 must
+	// wait 10ms each, so the 4 take at least 20ms. Each delay is truncated
+	// to a whole nanosecond, so allow for that.
+	if elapsed, want := time.Since(start), 20*time.Millisecond-time.Microsecond; elapsed < want {
+		t.Errorf("4 requests took %s, want at least %s", elapsed, want)
+	}
+}
+
+func TestLimiter_Wait_cancel(t *testing.T) {
+	l := NewLimiter(0.001, 1)
+	if err := l.Wait(context.Background(), "op"); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := l.Wait(ctx, "op"); err != context.DeadlineExceeded {
+		t.Errorf("got error %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestLimiter_SetOperationLimit(t *testing.T) {
+	l := NewLimiter(0.001, 1)
+	l.SetOperationLimit("DescribeEvents", 1000, 10)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	for i := 0; i < 10; i++ {
+		if err := l.Wait(ctx, "DescribeEvents"); err != nil {
+			t.Fatalf("DescribeEvents was limited by the default bucket: %v", err)
+		}
+	}
+	if err := l.Wait(ctx, "DescribeEnvironments"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLimiter_adaptive(t *testing.T) {
+	l := NewLimiter(16, 1)
+	l.Throttled("op")
+	if got, want := l.Rate("op"), 8.0; got != want {
+		t.Errorf("after throttling, got rate %v, want %v", got, want)
+	}
+	for i := 0; i < 10; i++ {
+		l.Throttled("op")
+	}
+	if got, want := l.Rate("op"), 1.0; got != want {
+		t.Errorf("after repeated throttling, got rate %v, want minimum %v", got, want)
+	}
+	for i := 0; i < 20; i++ {
+		l.Succeeded("op")
+	}
+	if got, want := l.Rate("op"), 16.0; got != want {
+		t.Errorf("after recovering, got rate %v, want %v", got, want)
+	}
+}
+
+func TestClient_Limiter_throttling(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		writeJSON(w, `{"Error": {"Code": "Throttling", "Message": "Rate exceeded", "Type": "Sender"}, "RequestId": "r"}`)
+	})
+
+	client.Limiter = NewLimiter(10, 1)
+	_, err := client.DescribeEnvironments(&DescribeEnvironmentsParams{})
+	if !IsThrottling(err) {
+		t.Fatalf("got error %v, want throttling error", err)
+	}
+	if e := err.(*APIError); e.Code != "Throttling" || e.RequestID != "r" {
+		t.Errorf("got APIError %+v", e)
+	}
+	if got, want := client.Limiter.Rate("DescribeEnvironments"), 5.0; got != want {
+		t.Errorf("got rate %v after throttling error, want %v", got, want)
+	}
+}
@@ -0,0 +1,92 @@
+package elasticbeanstalk
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrWaitTimeout is returned by the EnvironmentWaiter methods if the
+// environment doesn't reach the awaited state before the timeout.
+var ErrWaitTimeout = errors.New("timed out waiting for environment")
+
+// An EnvironmentWaiter waits for an environment to reach a status (such as
+// "Ready" after it is updated) or other state.
+type EnvironmentWaiter struct {
+	API             API
+	ApplicationName string
+	EnvironmentName string
+
+	// Interval is how long to wait between checks of the environment's
+	// status. If zero, 5 seconds is used.
+	Interval time.Duration
+
+	// Timeout, if positive, is how long to wait before giving up with
+	// ErrWaitTimeout.
+	Timeout time.Duration
+
+	// Progress, if set, is called with the environment's description after
+	// each check, such as to print its status.
+	Progress func(*EnvironmentDescription)
+}
+
+// Wait checks the environment's status until it is status, and then returns
+// its description. It returns an error if the environment is terminated
+// (unless status is "Terminated") or doesn't exist. If status is
+// "Terminated" and the environment no longer exists, Wait returns a nil
+// description and a nil error.
+func (w *EnvironmentWaiter) Wait(status string) (*EnvironmentDescription, error) {
+	return w.wait(func(env *EnvironmentDescription) bool { return env.Status == status }, status == "Terminated")
+}
+
+// WaitUntil checks the environment until done returns true for its
+// description, and then returns the description. It returns an error if the
+// environment is terminated (and done returns false) or doesn't exist.
+func (w *EnvironmentWaiter) WaitUntil(done func(*EnvironmentDescription) bool) (*EnvironmentDescription, error) {
+	return w.wait(done, false)
+}
+
+// wait implements Wait and WaitUntil. If missingOK, a nonexistent
+// environment ends the wait with a nil description and a nil error.
+func (w *EnvironmentWaiter) wait(done func(*EnvironmentDescription) bool, missingOK bool) (*EnvironmentDescription, error) {
+	interval := w.Interval
+	if interval == 0 {
+		interval = 5 * time.Second
+	}
+	var deadline time.Time
+	if w.Timeout > 0 {
+		deadline = time.Now().Add(w.Timeout)
+	}
+	for {
+		envs, err := w.API.DescribeEnvironments(&DescribeEnvironmentsParams{ApplicationName: w.ApplicationName, EnvironmentName: w.EnvironmentName})
+		if err != nil {
+			return nil, err
+		}
+		env := CurrentEnvironment(envs)
+		if env == nil {
+			if missingOK {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("environment %q not found", w.EnvironmentName)
+		}
+		if w.Progress != nil {
+			w.Progress(env)
+		}
+		switch {
+		case done(env):
+			return env, nil
+		case env.Status == "Terminated":
+			return env, fmt.Errorf("environment %q was terminated", w.EnvironmentName)
+		case !deadline.IsZero() && time.Now().After(deadline):
+			return env, ErrWaitTimeout
+		}
+		sleep := interval
+		if !deadline.IsZero() {
+			// Check one last time at the deadline.
+			if untilDeadline := time.Until(deadline); untilDeadline < sleep {
+				sleep = untilDeadline
+			}
+		}
+		time.Sleep(sleep)
+	}
+}
@@ -0,0 +1,144 @@
+package elasticbeanstalk
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestEnvironmentWaiter(t *testing.T) {
+	setup()
+	defer teardown()
+
+	statuses := []string{"Updating", "Updating", "Ready"}
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		status := statuses[0]
+		if len(statuses) > 1 {
+			statuses = statuses[1:]
+		}
+		writeJSON(w, `{"DescribeEnvironmentsResponse": {"DescribeEnvironmentsResult": {"Environments": [{"EnvironmentName": "env", "Status": "`+status+`"}]}}}`)
+	})
+
+	var seen []string
+	w := &EnvironmentWaiter{
+		API:             client,
+		EnvironmentName: "env",
+		Interval:        time.Millisecond,
+		Progress:        func(env *EnvironmentDescription) { seen = append(seen, env.Status) },
+	}
+	env, err := w.Wait("Ready")
+	if err != nil {
+		t.Fatalf("Wait returned error: %v", err)
+	}
+	if env.Status != "Ready" {
+		t.Errorf("got status %q, want Ready", env.Status)
+	}
+	if len(seen) != 3 {
+		t.Errorf("got %d progress calls (%v), want 3", len(seen), seen)
+	}
+}
+
+func TestEnvironmentWaiter_replacedEnvironment(t *testing.T) {
+	setup()
+	defer teardown()
+
+	// A terminated environment with the same name as the awaited one is
+	// returned first.
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, `{"DescribeEnvironmentsResponse": {"DescribeEnvironmentsResult": {"Environments": [{"EnvironmentId": "e-old", "EnvironmentName": "env", "Status": "Terminated"}, {"EnvironmentId": "e-new", "EnvironmentName": "env", "Status": "Ready"}]}}}`)
+	})
+
+	w := &EnvironmentWaiter{API: client, EnvironmentName: "env", Interval: time.Millisecond}
+	env, err := w.Wait("Ready")
+	if err != nil {
+		t.Fatalf("Wait returned error: %v", err)
+	}
+	if env.EnvironmentId != "e-new" {
+		t.Errorf("got environment %q, want e-new", env.EnvironmentId)
+	}
+}
+
+func TestEnvironmentWaiter_Timeout(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, `{"DescribeEnvironmentsResponse": {"DescribeEnvironmentsResult": {"Environments": [{"EnvironmentName": "env", "Status": "Updating"}]}}}`)
+	})
+
+	w := &EnvironmentWaiter{API: client, EnvironmentName: "env", Interval: time.Millisecond, Timeout: 20 * time.Millisecond}
+	if _, err := w.Wait("Ready"); err != ErrWaitTimeout {
+		t.Errorf("got error %v, want ErrWaitTimeout", err)
+	}
+}
+
+func TestEnvironmentWaiter_TimeoutShorterThanInterval(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, `{"DescribeEnvironmentsResponse": {"DescribeEnvironmentsResult": {"Environments": [{"EnvironmentName": "env", "Status": "Updating"}]}}}`)
+	})
+
+	// The environment is checked again at the deadline, rather than giving
+	// up after the first check because the next one would be too late.
+	var checks int
+	w := &EnvironmentWaiter{
+		API:             client,
+		EnvironmentName: "env",
+		Interval:        time.Hour,
+		Timeout:         20 * time.Millisecond,
+		Progress:        func(*EnvironmentDescription) { checks++ },
+	}
+	start := time.Now()
+	if _, err := w.Wait("Ready"); err != ErrWaitTimeout {
+		t.Errorf("got error %v, want ErrWaitTimeout", err)
+	}
+	if elapsed := time.Since(start); elapsed < w.Timeout {
+		t.Errorf("Wait gave up after %s, before the %s timeout", elapsed, w.Timeout)
+	}
+	if checks != 2 {
+		t.Errorf("got %d checks, want 2", checks)
+	}
+}
+
+func TestEnvironmentWaiter_Terminated(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, `{"DescribeEnvironmentsResponse": {"DescribeEnvironmentsResult": {"Environments": []}}}`)
+	})
+
+	w := &EnvironmentWaiter{API: client, EnvironmentName: "env", Interval: time.Millisecond}
+	if _, err := w.Wait("Ready"); err == nil {
+		t.Error("Wait for Ready of missing environment returned nil error")
+	}
+	if env, err := w.Wait("Terminated"); env != nil || err != nil {
+		t.Errorf("Wait for Terminated of missing environment returned %v, %v; want nil, nil", env, err)
+	}
+}
+
+func TestEnvironmentWaiter_WaitUntil(t *testing.T) {
+	setup()
+	defer teardown()
+
+	healths := []string{"Grey", "Yellow", "Green"}
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		health := healths[0]
+		if len(healths) > 1 {
+			healths = healths[1:]
+		}
+		writeJSON(w, `{"DescribeEnvironmentsResponse": {"DescribeEnvironmentsResult": {"Environments": [{"EnvironmentName": "env", "Status": "Ready", "Health": "`+health+`"}]}}}`)
+	})
+
+	w := &EnvironmentWaiter{API: client, EnvironmentName: "env", Interval: time.Millisecond}
+	env, err := w.WaitUntil(func(env *EnvironmentDescription) bool { return env.Health == "Green" })
+	if err != nil {
+		t.Fatalf("WaitUntil returned error: %v", err)
+	}
+	if env.Health != "Green" {
+		t.Errorf("got health %q, want Green", env.Health)
+	}
+}